@@ -0,0 +1,207 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+
+	"podcaster/internal/bot"
+)
+
+var (
+	durationType  = reflect.TypeOf(time.Duration(0))
+	int64sType    = reflect.TypeOf([]int64(nil))
+	stringsType   = reflect.TypeOf([]string(nil))
+	stringMapType = reflect.TypeOf(map[string]string(nil))
+)
+
+// LoadConfigFromEnv fills every bot.Config field from the environment
+// variable named by its env tag. Unset variables leave the zero value, so
+// bot.New applies its defaults; malformed values exit with an error.
+func LoadConfigFromEnv() bot.Config {
+	var cfg bot.Config
+	v := reflect.ValueOf(&cfg).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := t.Field(i).Tag.Get("env")
+		if name == "" {
+			continue
+		}
+		f := v.Field(i)
+		switch {
+		case f.Type() == durationType:
+			f.SetInt(int64(envDuration(name)))
+		case f.Type() == int64sType:
+			f.Set(reflect.ValueOf(envIDs(name)))
+		case f.Type() == stringsType:
+			f.Set(reflect.ValueOf(envList(name)))
+		case f.Type() == stringMapType:
+			f.Set(reflect.ValueOf(envMap(name)))
+		case f.Kind() == reflect.Int:
+			f.SetInt(int64(envInt(name)))
+		case f.Kind() == reflect.Float64:
+			f.SetFloat(envFloat(name))
+		case f.Kind() == reflect.Bool:
+			f.SetBool(envBool(name))
+		case f.Kind() == reflect.String:
+			f.SetString(os.Getenv(name))
+		default:
+			log.Fatalf("%s: unsupported config field type %s", name, f.Type())
+		}
+	}
+	if bad := nonPositiveEnv(positiveEnv...); len(bad) > 0 {
+		log.Fatalf("%s must be positive when set; leave it unset for the default", strings.Join(bad, ", "))
+	}
+	return cfg
+}
+
+// positiveEnv are integer variables whose zero value selects a default, so
+// setting them to zero or below is a mistake rather than a choice.
+var positiveEnv = []string{"NUM_TOPICS"}
+
+// openAIConfig builds the OpenAI client config from OPENAI_BASE_URL and
+// OPENAI_ORG_ID. A base URL on openai.azure.com selects Azure OpenAI, with
+// deployments named after the models.
+func openAIConfig(key string) openai.ClientConfig {
+	base := os.Getenv("OPENAI_BASE_URL")
+	if base == "" {
+		cfg := openai.DefaultConfig(key)
+		cfg.OrgID = os.Getenv("OPENAI_ORG_ID")
+		return cfg
+	}
+
+	u, err := url.Parse(base)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		log.Fatalf("OPENAI_BASE_URL: %q is not an http(s) URL", base)
+	}
+	var cfg openai.ClientConfig
+	if strings.HasSuffix(u.Hostname(), ".openai.azure.com") {
+		cfg = openai.DefaultAzureConfig(key, base)
+	} else {
+		cfg = openai.DefaultConfig(key)
+		cfg.BaseURL = strings.TrimRight(base, "/")
+	}
+	cfg.OrgID = os.Getenv("OPENAI_ORG_ID")
+	return cfg
+}
+
+func envInt(name string) int {
+	v := os.Getenv(name)
+	if v == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		log.Fatalf("%s: %v", name, err)
+	}
+	return n
+}
+
+func envFloat(name string) float64 {
+	v := os.Getenv(name)
+	if v == "" {
+		return 0
+	}
+	n, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		log.Fatalf("%s: %v", name, err)
+	}
+	return n
+}
+
+func envList(name string) []string {
+	var items []string
+	for _, f := range strings.Split(os.Getenv(name), ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			items = append(items, f)
+		}
+	}
+	return items
+}
+
+// envMap parses a JSON object of strings, since values such as prompt
+// templates may contain commas.
+func envMap(name string) map[string]string {
+	v := os.Getenv(name)
+	if v == "" {
+		return nil
+	}
+	var m map[string]string
+	if err := json.Unmarshal([]byte(v), &m); err != nil {
+		log.Fatalf("%s: %v", name, err)
+	}
+	return m
+}
+
+func envIDs(name string) []int64 {
+	var ids []int64
+	for _, f := range envList(name) {
+		id, err := strconv.ParseInt(f, 10, 64)
+		if err != nil {
+			log.Fatalf("%s: %v", name, err)
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// requireEnv exits with a message listing every unset variable in names.
+func requireEnv(names ...string) {
+	if missing := missingEnv(names...); len(missing) > 0 {
+		log.Fatalf("missing required environment variables: %s (see .env.template)", strings.Join(missing, ", "))
+	}
+}
+
+// missingEnv returns the variables in names that are unset or empty.
+func missingEnv(names ...string) []string {
+	var missing []string
+	for _, name := range names {
+		if os.Getenv(name) == "" {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// nonPositiveEnv returns the variables in names that are set to an integer
+// below one. Unset or empty variables are left to their defaults.
+func nonPositiveEnv(names ...string) []string {
+	var bad []string
+	for _, name := range names {
+		if n, err := strconv.Atoi(os.Getenv(name)); err == nil && n < 1 {
+			bad = append(bad, name)
+		}
+	}
+	return bad
+}
+
+func envBool(name string) bool {
+	v := os.Getenv(name)
+	if v == "" {
+		return false
+	}
+	ok, err := strconv.ParseBool(v)
+	if err != nil {
+		log.Fatalf("%s: %v", name, err)
+	}
+	return ok
+}
+
+func envDuration(name string) time.Duration {
+	v := os.Getenv(name)
+	if v == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Fatalf("%s: %v", name, err)
+	}
+	return d
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMissingEnv(t *testing.T) {
+	t.Setenv("TELEGRAM_BOT_TOKEN", "token")
+	t.Setenv("OPENAI_API_KEY", "")
+	got := missingEnv("TELEGRAM_BOT_TOKEN", "OPENAI_API_KEY", "PODCASTER_TEST_UNSET")
+	if want := []string{"OPENAI_API_KEY", "PODCASTER_TEST_UNSET"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("missingEnv = %q, want %q", got, want)
+	}
+
+	t.Setenv("OPENAI_API_KEY", "key")
+	if got := missingEnv("TELEGRAM_BOT_TOKEN", "OPENAI_API_KEY"); len(got) != 0 {
+		t.Errorf("missingEnv = %q with both set", got)
+	}
+}
+
+func TestLoadConfigFromEnv(t *testing.T) {
+	t.Setenv("OPENAI_CHAT_MODEL", "gpt-4o-mini")
+	t.Setenv("NUM_TOPICS", "3")
+	t.Setenv("OPENAI_TIMEOUT", "90s")
+	t.Setenv("ALLOWED_USER_IDS", "1, 2")
+	t.Setenv("CATEGORIES", "Science,History")
+	t.Setenv("SEND_SCRIPT_TEXT", "true")
+
+	cfg := LoadConfigFromEnv()
+	if cfg.ChatModel != "gpt-4o-mini" || cfg.NumTopics != 3 || cfg.AITimeout != 90*time.Second || !cfg.SendScriptText {
+		t.Errorf("loaded %+v", cfg)
+	}
+	if !reflect.DeepEqual(cfg.AllowedIDs, []int64{1, 2}) || !reflect.DeepEqual(cfg.Categories, []string{"Science", "History"}) {
+		t.Errorf("loaded IDs %v and categories %q", cfg.AllowedIDs, cfg.Categories)
+	}
+}
+
+func TestNonPositiveEnv(t *testing.T) {
+	t.Setenv("NUM_TOPICS", "0")
+	t.Setenv("PODCASTER_TEST_NEGATIVE", "-2")
+	t.Setenv("PODCASTER_TEST_POSITIVE", "3")
+	t.Setenv("PODCASTER_TEST_EMPTY", "")
+	got := nonPositiveEnv("NUM_TOPICS", "PODCASTER_TEST_NEGATIVE", "PODCASTER_TEST_POSITIVE", "PODCASTER_TEST_EMPTY", "PODCASTER_TEST_UNSET")
+	if want := []string{"NUM_TOPICS", "PODCASTER_TEST_NEGATIVE"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("nonPositiveEnv = %q, want %q", got, want)
+	}
+}
@@ -1,8 +1,14 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	openai "github.com/sashabaranov/go-openai"
 
 	"podcaster/internal/bot"
 )
@@ -10,14 +16,39 @@ import (
 func main() {
 	tgToken := os.Getenv("TELEGRAM_BOT_TOKEN")
 	aiKey := os.Getenv("OPENAI_API_KEY")
+	mock := envBool("MOCK_MODE")
+	if mock {
+		requireEnv("TELEGRAM_BOT_TOKEN")
+	} else {
+		requireEnv("TELEGRAM_BOT_TOKEN", "OPENAI_API_KEY")
+	}
+
+	cfg := LoadConfigFromEnv()
+
+	tg, err := tgbotapi.NewBotAPI(tgToken)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	b, err := bot.New(tgToken, aiKey)
+	var ai bot.AIClient = openai.NewClientWithConfig(openAIConfig(aiKey))
+	if mock {
+		log.Println("MOCK_MODE is set: using canned AI responses")
+		ai = bot.MockAI{}
+	}
+
+	b, err := bot.New(tg, ai, cfg)
 	if err != nil {
 		log.Fatal(err)
 	}
+	info := buildInfo()
+	b.SetBuildInfo(info)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	log.Println("bot is starting...")
-	if err := b.Run(); err != nil {
+	log.Printf("bot %s (%s) is starting...", info.Version, info.Commit)
+	if err := b.Run(ctx); err != nil {
 		log.Fatal(err)
 	}
+	log.Println("bot stopped")
 }
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"runtime"
+	"runtime/debug"
+
+	"podcaster/internal/bot"
+)
+
+// Set at build time, e.g.
+//
+//	go build -ldflags "-X main.version=v1.2.0 -X main.commit=$(git rev-parse --short HEAD)"
+var (
+	version = "dev"
+	commit  = ""
+)
+
+// buildInfo reports this binary's version. Without -ldflags, the commit
+// falls back to the VCS revision Go embeds when building from a checkout.
+func buildInfo() bot.BuildInfo {
+	c := commit
+	if c == "" {
+		if info, ok := debug.ReadBuildInfo(); ok {
+			for _, s := range info.Settings {
+				if s.Key == "vcs.revision" {
+					c = s.Value
+				}
+			}
+		}
+	}
+	if len(c) > 12 {
+		c = c[:12]
+	}
+	return bot.BuildInfo{Version: version, Commit: c, GoVersion: runtime.Version()}
+}
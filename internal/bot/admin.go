@@ -0,0 +1,150 @@
+package bot
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// secretFieldSuffixes mark Config fields whose values must never be shown.
+// They match the end of a name only, so DailyTokenBudget stays visible.
+var secretFieldSuffixes = []string{"Secret", "Token", "Key", "Password"}
+
+// secretPathFields are Config URLs whose path is itself a secret, so only
+// their scheme and host are shown.
+var secretPathFields = []string{"TelegramWebhookURL", "WebhookURL"}
+
+func (b *Bot) isAdmin(userID int64) bool {
+	for _, id := range b.cfg.AdminIDs {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// handleConfigCommand shows the effective configuration to admins.
+func (b *Bot) handleConfigCommand(chatID int64, from *tgbotapi.User) {
+	if from == nil || !b.isAdmin(from.ID) {
+		b.tg.Send(tgbotapi.NewMessage(chatID, b.localized(chatID, msgAdminOnly)))
+		return
+	}
+	for _, part := range splitText(describeConfig(b.cfg), maxMessageLen) {
+		b.tg.Send(tgbotapi.NewMessage(chatID, part))
+	}
+}
+
+// describeConfig lists every Config field, redacting secrets.
+func describeConfig(cfg Config) string {
+	var sb strings.Builder
+	sb.WriteString("Effective configuration:\n")
+
+	v := reflect.ValueOf(cfg)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := t.Field(i).Name
+		val := fmt.Sprint(v.Field(i).Interface())
+		switch {
+		case v.Field(i).IsZero():
+		case isSecretField(name):
+			val = "[redacted]"
+		case isSecretPathField(name):
+			val = redactPath(val)
+		}
+		if val == "" {
+			val = "(unset)"
+		}
+		fmt.Fprintf(&sb, "%s: %s\n", name, val)
+	}
+	return sb.String()
+}
+
+func isSecretPathField(name string) bool {
+	for _, f := range secretPathFields {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+// redactPath keeps a URL's scheme and host, hiding the rest.
+func redactPath(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "[redacted]"
+	}
+	return u.Scheme + "://" + u.Host + "/[redacted]"
+}
+
+func isSecretField(name string) bool {
+	for _, suffix := range secretFieldSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// broadcastInterval keeps broadcasts under Telegram's ~30 messages per
+// second limit.
+const broadcastInterval = time.Second / 30
+
+// knownUsers returns the allowed users with state in memory or in the
+// store, including those not seen since the last restart, in ID order.
+func (b *Bot) knownUsers() []int64 {
+	var stored []int64
+	if b.store != nil {
+		var err error
+		if stored, err = b.store.Users(); err != nil {
+			b.log.Error("list stored users", "err", err)
+		}
+	}
+
+	b.mu.Lock()
+	ids := stored
+	for id := range b.states {
+		ids = append(ids, id)
+	}
+	b.mu.Unlock()
+
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+	// States saved before the user was removed from the allowlist linger,
+	// but must not get messages.
+	return slices.DeleteFunc(ids, func(id int64) bool { return !b.isAllowed(id) })
+}
+
+// handleBroadcast sends text to every known user in the background and
+// reports the outcome to the admin who asked.
+func (b *Bot) handleBroadcast(chatID int64, text string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		b.tg.Send(tgbotapi.NewMessage(chatID, b.localized(chatID, msgBroadcastUsage)))
+		return
+	}
+
+	ids := b.knownUsers()
+
+	go func() {
+		tick := time.NewTicker(broadcastInterval)
+		defer tick.Stop()
+
+		sent, failed := 0, 0
+		for _, id := range ids {
+			<-tick.C
+			if _, err := b.tg.Send(tgbotapi.NewMessage(id, text)); err != nil {
+				b.log.Error("broadcast", "user_id", id, "err", err)
+				failed++
+				continue
+			}
+			sent++
+		}
+		b.tg.Send(tgbotapi.NewMessage(chatID, b.localizedf(chatID, msgBroadcastSent, sent, failed)))
+	}()
+}
@@ -0,0 +1,71 @@
+package bot
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDescribeConfigRedactsSecrets(t *testing.T) {
+	out := describeConfig(Config{
+		WebhookSecret:      "hook-secret",
+		FeedSecret:         "feed-secret",
+		WebhookURL:         "https://hooks.example.com/T000/B000/abcdef",
+		TelegramWebhookURL: "https://bot.example.com/hook/s3cret",
+		DailyTokenBudget:   5000,
+	})
+	for _, leak := range []string{"hook-secret", "feed-secret", "abcdef", "s3cret"} {
+		if strings.Contains(out, leak) {
+			t.Errorf("config shows %q:\n%s", leak, out)
+		}
+	}
+	for _, want := range []string{
+		"WebhookSecret: [redacted]",
+		"FeedSecret: [redacted]",
+		"WebhookURL: https://hooks.example.com/[redacted]",
+		"TelegramWebhookURL: https://bot.example.com/[redacted]",
+		"DailyTokenBudget: 5000",
+	} {
+		if !strings.Contains(out, want+"\n") {
+			t.Errorf("config lacks %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestBroadcastReachesStoredUsers(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 7 and 8 used the bot before a restart; 9 has since lost access.
+	for _, id := range []int64{7, 8, 9} {
+		if err := store.Save(id, &UserState{WaitingFor: StateInitial}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	b, tg, _ := newTestBot(t, Config{StateDir: dir, AdminIDs: []int64{testUser}, AllowedIDs: []int64{testUser, 7, 8}})
+	b.handleUpdate(commandWith("broadcast", "Hello all"))
+
+	report := fmt.Sprintf(messages["en"][msgBroadcastSent], 3, 0)
+	deadline := time.After(5 * time.Second)
+	for !slices.Contains(tg.texts(), report) {
+		select {
+		case <-deadline:
+			t.Fatalf("sent %q, want the report %q", tg.texts(), report)
+		case <-time.After(time.Millisecond):
+		}
+	}
+	var got []int64
+	for _, m := range tg.messages() {
+		if m.Text == "Hello all" {
+			got = append(got, m.ChatID)
+		}
+	}
+	if want := []int64{7, 8, testUser}; !slices.Equal(got, want) {
+		t.Errorf("broadcast went to %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,142 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// AIClient is the part of the OpenAI API the bot uses. *openai.Client
+// implements it.
+type AIClient interface {
+	CreateChatCompletion(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error)
+	CreateSpeech(ctx context.Context, req openai.CreateSpeechRequest) (openai.RawResponse, error)
+	CreateImage(ctx context.Context, req openai.ImageRequest) (openai.ImageResponse, error)
+}
+
+// ErrNoChoices is returned by chat when the model returns no choices.
+var ErrNoChoices = errors.New("bot: completion returned no choices")
+
+// chat sends a completion for prompt on userID's behalf, preceded by a
+// system message when system is set, bounded by the configured timeout,
+// and returns the reply text.
+func (b *Bot) chat(ctx context.Context, userID int64, system, prompt string) (string, error) {
+	return b.complete(ctx, userID, system, prompt, nil)
+}
+
+// chatJSON is chat in JSON mode: the reply is a JSON object. The prompt
+// must mention JSON, as the API requires.
+func (b *Bot) chatJSON(ctx context.Context, userID int64, system, prompt string) (string, error) {
+	return b.complete(ctx, userID, system, prompt, &openai.ChatCompletionResponseFormat{
+		Type: openai.ChatCompletionResponseFormatTypeJSONObject,
+	})
+}
+
+func (b *Bot) complete(ctx context.Context, userID int64, system, prompt string, format *openai.ChatCompletionResponseFormat) (string, error) {
+	release, err := b.acquireAI(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(ctx, b.cfg.AITimeout)
+	defer cancel()
+
+	var messages []openai.ChatCompletionMessage
+	if system != "" {
+		messages = append(messages, openai.ChatCompletionMessage{Role: openai.ChatMessageRoleSystem, Content: system})
+	}
+	messages = append(messages, openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, Content: prompt})
+
+	start := time.Now()
+	var resp openai.ChatCompletionResponse
+	err = withRetry(ctx, func() (err error) {
+		resp, err = b.ai.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+			Model:          b.cfg.ChatModel,
+			Messages:       messages,
+			ResponseFormat: format,
+		})
+		return err
+	})
+	if err != nil {
+		b.metrics.aiError(err)
+		b.log.Error("chat completion", "user_id", userID, "model", b.cfg.ChatModel, "latency", time.Since(start), "err", err)
+		return "", err
+	}
+
+	// Usage is zero when the API, a proxy or MOCK_MODE omits it, which
+	// simply counts nothing.
+	usage := resp.Usage
+	b.spendAI(usage.TotalTokens, 0)
+	b.metrics.aiTokens(usage.PromptTokens, usage.CompletionTokens)
+	b.recordTokens(userID, usage.TotalTokens)
+
+	// A content-filter block can come back with no choices at all.
+	if len(resp.Choices) == 0 {
+		b.metrics.aiError(ErrNoChoices)
+		b.log.Error("chat completion", "user_id", userID, "model", b.cfg.ChatModel, "latency", time.Since(start), "err", ErrNoChoices)
+		return "", ErrNoChoices
+	}
+	reply := resp.Choices[0].Message.Content
+	b.log.Info("chat completion", "user_id", userID, "model", b.cfg.ChatModel, "latency", time.Since(start),
+		"prompt_tokens", usage.PromptTokens, "completion_tokens", usage.CompletionTokens,
+		"tokens", usage.TotalTokens, "reply", truncateLog(reply))
+	return reply, nil
+}
+
+const maxLogText = 200
+
+// truncateLog shortens generated text for log output.
+func truncateLog(s string) string {
+	if r := []rune(s); len(r) > maxLogText {
+		return string(r[:maxLogText]) + "…"
+	}
+	return s
+}
+
+// sendAIError reports a failed OpenAI call in step, telling timeouts and
+// quota errors apart from other errors.
+func (b *Bot) sendAIError(userID int64, err error, step string) {
+	var text string
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		text = b.localized(userID, msgAITimeout)
+	case errors.Is(err, ErrNoChoices):
+		text = b.localized(userID, msgAINoChoices)
+	case errors.Is(err, ErrBudgetExhausted):
+		b.tg.Send(tgbotapi.NewMessage(userID, b.localized(userID, msgBudgetReached)))
+		return
+	case isQuotaError(err):
+		// Retrying cannot help until the operator tops up the account, so
+		// don't offer the categories again.
+		b.log.Error("openai quota exhausted", "user_id", userID, "err", err)
+		b.tg.Send(tgbotapi.NewMessage(userID, b.localized(userID, msgUnavailable)))
+		return
+	default:
+		b.sendError(userID, step)
+		return
+	}
+	b.sendFailure(userID, text, step)
+}
+
+// acquireAI waits for one of the Config.MaxConcurrentAI request slots, so
+// requests beyond the cap queue instead of hitting OpenAI's concurrency
+// limits. The returned func frees the slot. Once the daily budget is
+// spent it fails with ErrBudgetExhausted.
+func (b *Bot) acquireAI(ctx context.Context) (release func(), err error) {
+	if !b.budget.allow(time.Now()) {
+		return nil, ErrBudgetExhausted
+	}
+	if b.aiSlots == nil {
+		return func() {}, nil
+	}
+	select {
+	case b.aiSlots <- struct{}{}:
+		return func() { <-b.aiSlots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,207 @@
+package bot
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestChatModel(t *testing.T) {
+	tests := []struct {
+		name, model, want string
+	}{
+		{"configured", "test-model", "test-model"},
+		{"default", "", openai.GPT4o},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _, ai := newTestBot(t, Config{ChatModel: tt.model})
+			if b.cfg.ChatModel != tt.want {
+				t.Errorf("cfg.ChatModel = %q, want %q", b.cfg.ChatModel, tt.want)
+			}
+			var models []string
+			ai.chat = func(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+				models = append(models, req.Model)
+				return ai.MockAI.CreateChatCompletion(ctx, req)
+			}
+			b.handleUpdate(command("new"))
+			b.handleUpdate(tap(categoryPrefix + DefaultCategories[0]))
+			b.handleUpdate(tap(topicData(b.stateOf(testUser).TopicsGen, 0)))
+
+			if len(models) < 2 {
+				t.Fatalf("made %d chat calls, want topics and a script", len(models))
+			}
+			for _, m := range models {
+				if m != tt.want {
+					t.Errorf("chat used model %q, want %q", m, tt.want)
+				}
+			}
+		})
+	}
+}
+
+// hang blocks until the request's context ends, like an API that never
+// answers.
+func hang(ctx context.Context, _ openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	<-ctx.Done()
+	return openai.ChatCompletionResponse{}, ctx.Err()
+}
+
+func TestAITimeout(t *testing.T) {
+	b, tg, ai := newTestBot(t, Config{AITimeout: 20 * time.Millisecond})
+	ai.chat = hang
+	b.handleUpdate(command("new"))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.handleUpdate(tap(categoryPrefix + DefaultCategories[0]))
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the timeout did not fire")
+	}
+
+	if got := tg.texts(); got[len(got)-1] != messages["en"][msgAITimeout] {
+		t.Errorf("last message = %q, want the timeout notice", got[len(got)-1])
+	}
+}
+
+func TestSpeechTimeout(t *testing.T) {
+	b, tg, ai := newTestBot(t, Config{AITimeout: 20 * time.Millisecond})
+	ai.speech = func(ctx context.Context, _ openai.CreateSpeechRequest) (openai.RawResponse, error) {
+		<-ctx.Done()
+		return openai.RawResponse{}, ctx.Err()
+	}
+	b.recordAudio(testUser, "A short script.")
+
+	if tg.sentAudio() {
+		t.Error("audio was sent")
+	}
+	if got := tg.texts(); got[len(got)-1] != messages["en"][msgAITimeout] {
+		t.Errorf("last message = %q, want the timeout notice", got[len(got)-1])
+	}
+}
+
+func TestNoChoices(t *testing.T) {
+	noChoices := func(context.Context, openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+		return openai.ChatCompletionResponse{}, nil
+	}
+	tests := []struct {
+		name string
+		step string
+		run  func(b *Bot)
+	}{
+		{"topics", stepTopics, func(b *Bot) {
+			b.handleUpdate(command("new"))
+			b.handleUpdate(tap(categoryPrefix + DefaultCategories[0]))
+		}},
+		{"script", stepScript, func(b *Bot) {
+			b.handleTopicSelection(testUser, "Lighthouses")
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, tg, ai := newTestBot(t, Config{})
+			ai.chat = noChoices
+			tt.run(b)
+
+			msgs := tg.messages()
+			last := msgs[len(msgs)-1]
+			if last.Text != messages["en"][msgAINoChoices] {
+				t.Fatalf("last message = %q, want the no-choices notice", last.Text)
+			}
+			markup, _ := last.ReplyMarkup.(tgbotapi.InlineKeyboardMarkup)
+			if len(markup.InlineKeyboard) != 1 || *markup.InlineKeyboard[0][0].CallbackData != retryPrefix+tt.step {
+				t.Errorf("keyboard = %+v, want a retry of %s", markup.InlineKeyboard, tt.step)
+			}
+		})
+	}
+}
+
+func TestMaxConcurrentAI(t *testing.T) {
+	const limit = 2
+	b, _, ai := newTestBot(t, Config{MaxConcurrentAI: limit})
+	var (
+		mu             sync.Mutex
+		inFlight, most int
+	)
+	ai.chat = func(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+		mu.Lock()
+		inFlight++
+		most = max(most, inFlight)
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		return ai.MockAI.CreateChatCompletion(ctx, req)
+	}
+
+	var wg sync.WaitGroup
+	for user := int64(1); user <= 3*limit; user++ {
+		wg.Add(1)
+		go func(user int64) {
+			defer wg.Done()
+			b.handleTopicSelection(user, "Lighthouses")
+		}(user)
+	}
+	wg.Wait()
+
+	if most != limit {
+		t.Errorf("at most %d calls ran at once, want %d", most, limit)
+	}
+	for user := int64(1); user <= 3*limit; user++ {
+		if b.stateOf(user).ScriptText == "" {
+			t.Errorf("user %d got no script", user)
+		}
+	}
+}
+
+func TestAISlotWaitIsCancellable(t *testing.T) {
+	b, _, _ := newTestBot(t, Config{MaxConcurrentAI: 1})
+	release, err := b.acquireAI(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := b.acquireAI(ctx); err != context.Canceled {
+		t.Errorf("waiting on a full cap with a cancelled context returned %v", err)
+	}
+}
+
+// The real client must keep satisfying the interface the fakes stand in for.
+var _ AIClient = (*openai.Client)(nil)
+
+func TestFakeAIDrivesTheWholeFlow(t *testing.T) {
+	b, tg, ai := newTestBot(t, Config{})
+	var inputs []string
+	ai.speech = func(ctx context.Context, req openai.CreateSpeechRequest) (openai.RawResponse, error) {
+		inputs = append(inputs, req.Input)
+		return ai.MockAI.CreateSpeech(ctx, req)
+	}
+	b.handleUpdate(command("new"))
+	b.handleUpdate(tap(categoryPrefix + DefaultCategories[1]))
+	b.handleUpdate(tap(topicData(b.stateOf(testUser).TopicsGen, 2)))
+	script := b.stateOf(testUser).ScriptText
+	b.handleUpdate(b.reviewTap("approve"))
+
+	if !ai.prompted(DefaultCategories[1]) || !ai.prompted(mockTopics[2]) {
+		t.Errorf("prompts %q miss the category or topic", ai.prompts)
+	}
+	if len(inputs) != 1 || !strings.Contains(inputs[0], "mock episode") {
+		t.Errorf("speech inputs = %q, want the script %q", inputs, script)
+	}
+	if !tg.sentAudio() {
+		t.Error("no audio was sent")
+	}
+}
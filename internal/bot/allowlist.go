@@ -0,0 +1,45 @@
+package bot
+
+import tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+// newAllowlist returns the set of allowed user IDs, or nil to allow
+// everyone.
+func newAllowlist(ids []int64) map[int64]struct{} {
+	if len(ids) == 0 {
+		return nil
+	}
+	set := make(map[int64]struct{}, len(ids))
+	for _, id := range ids {
+		set[id] = struct{}{}
+	}
+	return set
+}
+
+// isAllowed reports whether userID may use the bot. Admins always may.
+func (b *Bot) isAllowed(userID int64) bool {
+	if b.allowed == nil {
+		return true
+	}
+	_, ok := b.allowed[userID]
+	return ok || b.isAdmin(userID)
+}
+
+// updateAllowed reports whether the sender of u may use the bot, checking
+// the same IDs as handleMessage and handleCallback.
+func (b *Bot) updateAllowed(u tgbotapi.Update) bool {
+	switch {
+	case u.Message != nil:
+		return b.isAllowed(senderID(u.Message))
+	case u.CallbackQuery != nil:
+		return u.CallbackQuery.From == nil || b.isAllowed(u.CallbackQuery.From.ID)
+	}
+	return true
+}
+
+// senderID identifies who sent msg, falling back to its chat.
+func senderID(msg *tgbotapi.Message) int64 {
+	if msg.From != nil {
+		return msg.From.ID
+	}
+	return msg.Chat.ID
+}
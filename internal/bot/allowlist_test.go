@@ -0,0 +1,62 @@
+package bot
+
+import (
+	"os"
+	"testing"
+)
+
+func TestIsAllowed(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		userID  int64
+		allowed bool
+	}{
+		{"empty list allows everyone", Config{}, testUser, true},
+		{"listed", Config{AllowedIDs: []int64{1, testUser}}, testUser, true},
+		{"not listed", Config{AllowedIDs: []int64{1}}, testUser, false},
+		{"admins always", Config{AllowedIDs: []int64{1}, AdminIDs: []int64{testUser}}, testUser, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _, _ := newTestBot(t, tt.cfg)
+			if got := b.isAllowed(tt.userID); got != tt.allowed {
+				t.Errorf("isAllowed(%d) = %v, want %v", tt.userID, got, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestDeniedUsers(t *testing.T) {
+	dir := t.TempDir()
+	b, tg, ai := newTestBot(t, Config{AllowedIDs: []int64{1}, StateDir: dir})
+
+	b.handleUpdate(command("new"))
+	b.handleUpdate(text("Lighthouses"))
+	b.handleUpdate(tap(categoryPrefix + DefaultCategories[0]))
+
+	texts := tg.texts()
+	if len(texts) != 2 {
+		t.Errorf("sent %q, want a notice per message", texts)
+	}
+	for _, got := range texts {
+		if got != messages["en"][msgUnauthorized] {
+			t.Errorf("a denied user was sent %q", got)
+		}
+	}
+	if got := tg.callbackAnswers(); len(got) != 1 || got[0] != messages["en"][msgUnauthorized] {
+		t.Errorf("answers = %q, want the unauthorized notice", got)
+	}
+	if len(ai.prompts) != 0 {
+		t.Errorf("a denied user reached the model: %q", ai.prompts)
+	}
+	b.mu.Lock()
+	_, hasState := b.states[testUser]
+	b.mu.Unlock()
+	if hasState {
+		t.Error("a denied user got state in memory")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("a denied user got state on disk: %v", entries)
+	}
+}
@@ -0,0 +1,84 @@
+package bot
+
+import (
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const maxAngleLen = 100
+
+var anglePresets = []string{"Beginner", "Advanced", "Controversial"}
+
+// anglePrefix marks angle preset buttons' callback data.
+const anglePrefix = "angle:"
+
+// handleAngleCommand regenerates topics for the current category from the
+// given angle, or offers preset angles when hint is empty.
+func (b *Bot) handleAngleCommand(userID int64, hint string) {
+	st := b.getState(userID)
+	b.mu.Lock()
+	category := st.Category
+	b.mu.Unlock()
+	if category == "" {
+		b.tg.Send(tgbotapi.NewMessage(userID, b.localized(userID, msgNeedCategory)))
+		return
+	}
+
+	hint = strings.TrimSpace(hint)
+	if hint == "" {
+		b.sendAngles(userID)
+		return
+	}
+	if r := []rune(hint); len(r) > maxAngleLen {
+		hint = string(r[:maxAngleLen])
+	}
+	b.applyAngle(userID, hint)
+}
+
+func (b *Bot) sendAngles(userID int64) {
+	var buttons []tgbotapi.InlineKeyboardButton
+	for _, a := range anglePresets {
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(a, anglePrefix+a))
+	}
+
+	msg := tgbotapi.NewMessage(userID, b.localized(userID, msgChooseAngle))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(buttons...),
+	)
+
+	st := b.getState(userID)
+	b.mu.Lock()
+	st.WaitingFor = StateAngle
+	b.mu.Unlock()
+
+	b.tg.Send(msg)
+}
+
+// handleAngleSelection applies a tapped angle preset. Taps outside angle
+// selection are ignored.
+func (b *Bot) handleAngleSelection(userID int64, angle string) {
+	st := b.getState(userID)
+	b.mu.Lock()
+	waiting := st.WaitingFor
+	b.mu.Unlock()
+	if waiting != StateAngle {
+		return
+	}
+
+	for _, a := range anglePresets {
+		if a == angle {
+			b.applyAngle(userID, angle)
+			return
+		}
+	}
+}
+
+func (b *Bot) applyAngle(userID int64, angle string) {
+	st := b.getState(userID)
+	b.mu.Lock()
+	st.Angle = angle
+	b.mu.Unlock()
+
+	b.generateTopics(userID)
+}
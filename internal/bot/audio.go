@@ -0,0 +1,123 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// DefaultMusicVolume is used when Config.MusicVolume is not positive.
+const DefaultMusicVolume = 0.15
+
+const ffmpegTimeout = 2 * time.Minute
+
+// errNoFFmpeg is returned when audio processing needs ffmpeg but it was
+// not found on PATH at startup.
+var errNoFFmpeg = errors.New("ffmpeg not found on PATH")
+
+// processAudio applies the configured audio post-processing to the speech
+// in f and returns the file to deliver. Each step that fails is logged and
+// skipped, so the user still gets their podcast; if nothing is applied, f
+// is returned unchanged.
+func (b *Bot) processAudio(ctx context.Context, userID int64, f *os.File, enc audioEncoding) *os.File {
+	out := f
+	// replace makes next the current output, removing the intermediate file
+	// it was made from.
+	replace := func(next *os.File) {
+		if out != f {
+			out.Close()
+			b.removeTempFile(out.Name())
+		}
+		out = next
+	}
+
+	if b.cfg.BackgroundMusic != "" {
+		mixed, err := b.mixBackground(ctx, userID, out.Name(), enc)
+		if err != nil {
+			b.log.Warn("skipping background music", "user_id", userID, "path", b.cfg.BackgroundMusic, "err", err)
+		} else {
+			replace(mixed)
+		}
+	}
+	if b.cfg.IntroAudio != "" || b.cfg.OutroAudio != "" {
+		joined, err := b.addJingles(ctx, userID, out.Name(), enc)
+		if err != nil {
+			b.log.Warn("skipping jingles", "user_id", userID, "err", err)
+		} else {
+			replace(joined)
+		}
+	}
+	return out
+}
+
+// mixBackground lays the looped background track under the speech at
+// Config.MusicVolume, trimmed to the speech's length.
+func (b *Bot) mixBackground(ctx context.Context, userID int64, speech string, enc audioEncoding) (*os.File, error) {
+	if _, err := os.Stat(b.cfg.BackgroundMusic); err != nil {
+		return nil, err
+	}
+	filter := fmt.Sprintf("[1:a]volume=%g[bg];[0:a][bg]amix=inputs=2:duration=first:dropout_transition=0:normalize=0",
+		b.cfg.MusicVolume)
+	return b.ffmpegAudio(ctx, userID, enc,
+		"-i", speech, "-stream_loop", "-1", "-i", b.cfg.BackgroundMusic, "-filter_complex", filter)
+}
+
+// jingleFormat is what every clip is resampled to before concatenation,
+// since ffmpeg's concat filter needs matching streams. 48 kHz is valid for
+// all the output encoders, including Opus.
+const jingleFormat = "aresample=48000,aformat=sample_fmts=fltp:channel_layouts=stereo"
+
+// addJingles plays the configured intro and outro clips before and after
+// the speech. Either clip may be unset.
+func (b *Bot) addJingles(ctx context.Context, userID int64, speech string, enc audioEncoding) (*os.File, error) {
+	var args []string
+	var filter strings.Builder
+	var n int
+	for _, in := range []string{b.cfg.IntroAudio, speech, b.cfg.OutroAudio} {
+		if in == "" {
+			continue
+		}
+		args = append(args, "-i", in)
+		fmt.Fprintf(&filter, "[%d:a]%s[a%d];", n, jingleFormat, n)
+		n++
+	}
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&filter, "[a%d]", i)
+	}
+	fmt.Fprintf(&filter, "concat=n=%d:v=0:a=1", n)
+	return b.ffmpegAudio(ctx, userID, enc, append(args, "-filter_complex", filter.String())...)
+}
+
+// ffmpegAudio runs ffmpeg with the given input arguments and returns its
+// output, encoded as enc in a new tracked temp file opened for reading. The
+// caller removes it with removeTempFile.
+func (b *Bot) ffmpegAudio(ctx context.Context, userID int64, enc audioEncoding, args ...string) (*os.File, error) {
+	if b.ffmpeg == "" {
+		return nil, errNoFFmpeg
+	}
+	out, err := b.createTempAudio(userID, enc.Ext)
+	if err != nil {
+		return nil, err
+	}
+	out.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, ffmpegTimeout)
+	defer cancel()
+	args = append([]string{"-y", "-loglevel", "error"}, args...)
+	args = append(args, "-c:a", enc.Codec, out.Name())
+	if output, err := exec.CommandContext(ctx, b.ffmpeg, args...).CombinedOutput(); err != nil {
+		b.removeTempFile(out.Name())
+		return nil, fmt.Errorf("ffmpeg: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	f, err := os.Open(out.Name())
+	if err != nil {
+		b.removeTempFile(out.Name())
+		return nil, err
+	}
+	return f, nil
+}
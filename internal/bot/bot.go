@@ -2,11 +2,18 @@ package bot
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	openai "github.com/sashabaranov/go-openai"
@@ -18,59 +25,372 @@ type UserState struct {
 	Topic      string
 	WaitingFor string
 	ScriptText string
+	Angle      string
+	// Title is the current script's episode title, used as its caption.
+	Title string
+	// Length is one of the Length* keys; empty means Medium. Like Prefs it
+	// survives /new.
+	Length string
+	// RegeneratedAt is when /regenerate last ran, for its cooldown.
+	RegeneratedAt time.Time
+	// EpisodeID is the repository ID of the current script, or zero.
+	EpisodeID int64
+	// Topics are the topics on the latest keyboard; its buttons carry
+	// indexes into Topics, since callback data is limited to 64 bytes.
+	Topics []string
+	// SuggestedTopics are the topics already offered for Category, so
+	// "More topics" can ask for different ones.
+	SuggestedTopics []string
+	// TopicsMessageID is the message showing Topics, whose keyboard later
+	// topic lists for the same category replace in place; zero if none.
+	TopicsMessageID int
+	// TopicsGen counts the topic lists shown. Topic buttons carry it, so a
+	// tap on an older list, even one edited in place, is ignored.
+	TopicsGen int
+	// ScriptGen counts the scripts shown for review, edits included.
+	// Review buttons carry it, so a tap under an older version is ignored.
+	// Both counters survive /new, so no older keyboard's number is reused.
+	ScriptGen int
+	// Page is the page shown of the category or topic keyboard the user is
+	// choosing from, when it has more options than fit on one.
+	Page int
+	// AudioAwaitsConsent is set while an approved ScriptText waits for the
+	// answer to the consent disclaimer, which then narrates it. Flow resets
+	// clear it, so a late answer does not narrate an abandoned script.
+	AudioAwaitsConsent bool
+	// Segments holds the sections of ScriptText when it was generated as a
+	// structured script; ScriptText is then their text without headers.
+	Segments []Segment
+
+	// Prefs survive /new and other flow resets.
+	Prefs Prefs
+}
+
+// Prefs holds a user's long-lived preferences.
+type Prefs struct {
+	Favorites []Favorite
+
+	// InstructionsConsent records the opt-in to expressive narration.
+	InstructionsConsent bool
+	ConsentAsked        bool
+
+	Show ShowProfile
+
+	// DeliveryFormat is FormatAudio (default) or FormatVoice.
+	DeliveryFormat string
+	// Quality is QualityStandard (default) or QualityHD.
+	Quality string
+
+	Stats Stats
+
+	// History and LastEpisodeID save the user's part of Bot.history and
+	// its ID counter, so replay buttons keep their episode after a restart.
+	History       []Episode
+	LastEpisodeID int64
+}
+
+// clone returns a deep copy of st. The caller must hold b.mu.
+func (st *UserState) clone() *UserState {
+	cp := *st
+	cp.Prefs.Favorites = append([]Favorite(nil), st.Prefs.Favorites...)
+	cp.Segments = append([]Segment(nil), st.Segments...)
+	cp.SuggestedTopics = append([]string(nil), st.SuggestedTopics...)
+	cp.Topics = append([]string(nil), st.Topics...)
+	cp.Prefs.Stats = st.Prefs.Stats.clone()
+	cp.Prefs.History = append([]Episode(nil), st.Prefs.History...)
+	return &cp
 }
 
 const (
 	StateInitial  = "initial"
 	StateCategory = "category"
 	StateTopic    = "topic"
+	StateAngle    = "angle"
+	// StateReviewScript shows a generated script for approval before any
+	// audio is generated; StateEditScript waits for a corrected script.
+	StateReviewScript = "review_script"
+	StateEditScript   = "edit_script"
 )
 
 // Bot wraps Telegram and OpenAI clients with user state management.
 type Bot struct {
-	tg *tgbotapi.BotAPI
-	ai *openai.Client
+	tg  Sender
+	ai  AIClient
+	cfg Config
+	log *slog.Logger
+
+	history *History
+	store   StateStore
+	repo    Repository
+	limiter *rateLimiter
+	metrics *Metrics
+	allowed map[int64]struct{}
+	// ffmpeg is the path of the ffmpeg binary, or empty if it is not
+	// installed.
+	ffmpeg string
+	build  BuildInfo
+	// ready is set once Telegram accepted the token and commands.
+	ready atomic.Bool
+	// aiSlots holds a token for every OpenAI request in flight; nil means
+	// unlimited.
+	aiSlots chan struct{}
+	// budget caps daily OpenAI usage; nil means unlimited.
+	budget *budget
+	// ctx is Run's context, which generation jobs derive from so shutdown
+	// cancels them.
+	ctx context.Context
+	// answered holds the IDs of callback queries Run answered before their
+	// handler ran, so the handler does not answer them again.
+	answered sync.Map
 
-	mu     sync.Mutex
-	states map[int64]*UserState
+	mu      sync.Mutex
+	states  map[int64]*UserState
+	jobs    map[int64]job
+	nextJob uint64
+	temp    map[string]struct{}
 }
 
-// New creates a Bot with the provided tokens.
-func New(tgToken, aiKey string) (*Bot, error) {
-	tg, err := tgbotapi.NewBotAPI(tgToken)
-	if err != nil {
+// Errors returned by New when a required client is missing.
+var (
+	ErrNoTelegram = errors.New("bot: Telegram client is required (is TELEGRAM_BOT_TOKEN set?)")
+	ErrNoAI       = errors.New("bot: AI client is required (is OPENAI_API_KEY set?)")
+)
+
+// New creates a Bot with the provided Telegram and AI clients and settings.
+func New(tg Sender, ai AIClient, cfg Config) (*Bot, error) {
+	if tg == nil {
+		return nil, ErrNoTelegram
+	}
+	if ai == nil {
+		return nil, ErrNoAI
+	}
+	cfg = cfg.withDefaults()
+	var store StateStore
+	if cfg.StateDir != "" {
+		var err error
+		if store, err = NewFileStore(cfg.StateDir); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.FeedAddr != "" && (cfg.FeedURL == "" || cfg.FeedSecret == "" || cfg.AudioDir == "" || cfg.DatabasePath == "") {
+		return nil, errors.New("bot: FeedAddr requires FeedURL, FeedSecret, AudioDir and DatabasePath")
+	}
+	if cfg.TelegramWebhookSecret != "" && !webhookSecretRe.MatchString(cfg.TelegramWebhookSecret) {
+		return nil, errors.New("bot: TELEGRAM_WEBHOOK_SECRET must be 1-256 letters, digits, _ or -")
+	}
+	if cfg.NumTopics < 1 || cfg.NumTopics > MaxNumTopics {
+		return nil, fmt.Errorf("bot: NUM_TOPICS must be between 1 and %d", MaxNumTopics)
+	}
+	if err := validateTemplates(cfg); err != nil {
 		return nil, err
 	}
-	ai := openai.NewClient(aiKey)
+	for _, cat := range cfg.Categories {
+		if len(categoryPrefix+cat) > maxCallbackData {
+			return nil, fmt.Errorf("bot: category %q is too long for a button", cat)
+		}
+	}
+	if cfg.DialogueVoice != "" && !isVoice(cfg.DialogueVoice) {
+		return nil, fmt.Errorf("bot: unknown DialogueVoice %q", cfg.DialogueVoice)
+	}
+	if _, ok := audioEncodings[cfg.AudioFormat]; !ok {
+		return nil, fmt.Errorf("bot: unknown AudioFormat %q", cfg.AudioFormat)
+	}
+	for _, clip := range []string{cfg.IntroAudio, cfg.OutroAudio} {
+		if clip == "" {
+			continue
+		}
+		if _, err := os.Stat(clip); err != nil {
+			return nil, fmt.Errorf("bot: jingle: %w", err)
+		}
+	}
+	for _, dir := range []string{cfg.AudioDir, cfg.OutputDir} {
+		if dir == "" {
+			continue
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+		if err := checkWritable(dir); err != nil {
+			return nil, err
+		}
+	}
+
+	var repo Repository = nopRepository{}
+	if cfg.DatabasePath != "" {
+		sqlite, err := OpenSQLite(cfg.DatabasePath)
+		if err != nil {
+			return nil, err
+		}
+		repo = sqlite
+	}
+
+	var metrics *Metrics
+	if cfg.MetricsAddr != "" {
+		metrics = NewMetrics()
+	}
+
+	ffmpeg, err := exec.LookPath("ffmpeg")
+	if err != nil && cfg.processesAudio() {
+		slog.Warn("ffmpeg not found on PATH, background music and jingles are disabled")
+	}
+
+	var usage *budget
+	if cfg.DailyTokenBudget > 0 || cfg.DailyCharBudget > 0 {
+		var path string
+		if cfg.StateDir != "" {
+			path = filepath.Join(cfg.StateDir, budgetFile)
+		}
+		if usage, err = newBudget(cfg.DailyTokenBudget, cfg.DailyCharBudget, path); err != nil {
+			return nil, fmt.Errorf("bot: load usage budget: %w", err)
+		}
+	}
+
+	var aiSlots chan struct{}
+	if cfg.MaxConcurrentAI > 0 {
+		aiSlots = make(chan struct{}, cfg.MaxConcurrentAI)
+	}
 
 	return &Bot{
-		tg:     tg,
-		ai:     ai,
-		states: make(map[int64]*UserState),
+		build:   BuildInfo{GoVersion: runtime.Version()},
+		ffmpeg:  ffmpeg,
+		aiSlots: aiSlots,
+		budget:  usage,
+		ctx:     context.Background(),
+		tg:      tg,
+		ai:      ai,
+		cfg:     cfg,
+		log:     slog.Default(),
+		history: NewHistory(cfg.HistoryMaxEpisodes),
+		store:   store,
+		repo:    repo,
+		limiter: newRateLimiter(cfg.RateLimit),
+		metrics: metrics,
+		allowed: newAllowlist(cfg.AllowedIDs),
+		states:  make(map[int64]*UserState),
+		jobs:    make(map[int64]job),
+		temp:    make(map[string]struct{}),
 	}, nil
 }
 
-// Run starts listening for updates and handling them.
-func (b *Bot) Run() error {
-	if _, err := b.tg.Request(tgbotapi.NewSetMyCommands(
-		tgbotapi.BotCommand{Command: "new", Description: "Start new podcast creation"},
-		tgbotapi.BotCommand{Command: "text", Description: "Get generated podcast text"},
-	)); err != nil {
+// Run listens for updates and handles them until ctx is cancelled.
+func (b *Bot) Run(ctx context.Context) error {
+	b.ctx = ctx
+	if b.cfg.HealthAddr != "" {
+		go b.serveHealth(ctx, b.cfg.HealthAddr)
+	}
+	if _, err := b.tg.GetMe(); err != nil {
 		return err
 	}
+	if _, err := b.tg.Request(tgbotapi.NewSetMyCommands(commands...)); err != nil {
+		return err
+	}
+	b.ready.Store(true)
+
+	b.removeOrphanedTempFiles(time.Now())
+	go b.runPruner(ctx)
+	if b.metrics != nil {
+		go b.serveMetrics(ctx, b.cfg.MetricsAddr)
+	}
+	if b.cfg.FeedAddr != "" {
+		go b.serveFeed(ctx, b.cfg.FeedAddr)
+	}
+	defer b.removeTempFiles()
+	if c, ok := b.repo.(io.Closer); ok {
+		defer c.Close()
+	}
 
-	u := tgbotapi.NewUpdate(0)
-	u.Timeout = 60
-	updates := b.tg.GetUpdatesChan(u)
+	workers := newPool(b.cfg.Workers, b.handleUpdate)
+	defer workers.stop()
 
-	for update := range updates {
-		if update.Message != nil {
-			b.handleMessage(update.Message)
-		} else if update.CallbackQuery != nil {
-			b.handleCallback(update.CallbackQuery)
+	updates, err := b.updates(ctx)
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case update, ok := <-updates:
+			if !ok {
+				return nil
+			}
+			// A user's updates run in order, so /cancel must reach their
+			// in-flight job before it waits in the queue behind it.
+			if update.Message != nil && update.Message.Command() == "cancel" {
+				b.cancelJob(update.Message.Chat.ID)
+			}
+			// /status only reads the job, so it skips the queue to report
+			// on the job it would otherwise wait for.
+			if update.Message != nil && update.Message.Command() == "status" {
+				workers.dispatchNow(update)
+				continue
+			}
+			if q := update.CallbackQuery; q != nil && b.updateAllowed(update) {
+				if userID, ok := callbackChatID(q); ok && workers.busy(userID) {
+					// Telegram stops waiting for an answer long before a
+					// generation ahead of this tap finishes.
+					b.answered.Store(q.ID, struct{}{})
+					b.tg.Request(tgbotapi.NewCallback(q.ID, b.localized(userID, msgQueued)))
+				}
+			}
+			if !workers.dispatch(update) {
+				userID, _ := updateUserID(update)
+				b.log.Warn("dropped update, too many queued", "user_id", userID)
+			}
 		}
 	}
-	return nil
+}
+
+// answerCallback answers a callback query, unless Run already did.
+func (b *Bot) answerCallback(queryID, text string) {
+	if _, ok := b.answered.LoadAndDelete(queryID); ok {
+		return
+	}
+	b.tg.Request(tgbotapi.NewCallback(queryID, text))
+}
+
+// SetLogger replaces the default slog logger.
+func (b *Bot) SetLogger(l *slog.Logger) {
+	b.log = l
+}
+
+func (b *Bot) handleUpdate(update tgbotapi.Update) {
+	userID, ok := updateUserID(update)
+	defer b.recoverUpdate(update, userID, ok)
+	// Denied users are still told so by the handlers, but get no state, in
+	// memory or in the store.
+	ok = ok && b.updateAllowed(update)
+
+	var before string
+	if ok {
+		before = b.waitingFor(userID)
+	}
+
+	switch {
+	case update.Message != nil:
+		b.log.Info("message", "user_id", userID, "command", update.Message.Command(), "state", before)
+		b.handleMessage(update.Message)
+	case update.CallbackQuery != nil:
+		b.log.Info("callback", "user_id", userID, "data", update.CallbackQuery.Data, "state", before)
+		b.handleCallback(update.CallbackQuery)
+	}
+	if !ok {
+		return
+	}
+
+	if after := b.waitingFor(userID); after != before {
+		b.log.Info("state transition", "user_id", userID, "from", before, "to", after)
+	}
+	b.saveState(userID)
+}
+
+func (b *Bot) waitingFor(userID int64) string {
+	st := b.getState(userID)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return st.WaitingFor
 }
 
 func (b *Bot) getState(userID int64) *UserState {
@@ -78,194 +398,637 @@ func (b *Bot) getState(userID int64) *UserState {
 	defer b.mu.Unlock()
 	st, ok := b.states[userID]
 	if !ok {
-		st = &UserState{WaitingFor: StateInitial}
+		st = b.loadState(userID)
 		b.states[userID] = st
 	}
 	return st
 }
 
-func (b *Bot) resetState(userID int64) {
+// loadState reads a user's state from the store, falling back to a fresh
+// state. The caller must hold b.mu.
+func (b *Bot) loadState(userID int64) *UserState {
+	if b.store != nil {
+		st, err := b.store.Load(userID)
+		if err != nil {
+			b.log.Error("load state", "user_id", userID, "err", err)
+		}
+		if st != nil {
+			b.history.Restore(userID, st.Prefs.History, st.Prefs.LastEpisodeID)
+			return st
+		}
+	}
+	return &UserState{WaitingFor: StateInitial}
+}
+
+// saveState writes a snapshot of a user's in-memory state to the store.
+func (b *Bot) saveState(userID int64) {
+	if b.store == nil {
+		return
+	}
+
 	b.mu.Lock()
-	b.states[userID] = &UserState{WaitingFor: StateInitial}
+	st, ok := b.states[userID]
+	var snapshot *UserState
+	if ok {
+		snapshot = st.clone()
+	}
 	b.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	if err := b.store.Save(userID, snapshot); err != nil {
+		b.log.Error("save state", "user_id", userID, "err", err)
+	}
 }
 
 func (b *Bot) handleMessage(msg *tgbotapi.Message) {
 	userID := msg.Chat.ID
+	if !b.isAllowed(senderID(msg)) {
+		b.tg.Send(tgbotapi.NewMessage(userID, b.localized(userID, msgUnauthorized)))
+		return
+	}
+	if !b.limiter.allow(userID, time.Now()) {
+		b.sendRateLimited(userID)
+		return
+	}
 	state := b.getState(userID)
 
-	switch msg.Text {
-	case "/new":
-		b.resetState(userID)
-		b.sendCategories(userID)
+	switch msg.Command() {
+	case "new", "category", "cancel":
+		b.step(userID, flowInput{Command: msg.Command()})
 		return
-	case "/text":
+	case "text":
 		b.handleTextRequest(userID)
 		return
+	case "help":
+		b.sendHelp(userID)
+		return
+	case "version":
+		b.sendVersion(userID)
+		return
+	case "stats":
+		b.sendStats(userID)
+		return
+	case "feed":
+		b.sendFeedLink(userID)
+		return
+	case "download":
+		b.handleDownload(userID)
+		return
+	case "history":
+		b.sendHistory(userID)
+		return
+	case "replay":
+		b.handleReplay(userID, msg.CommandArguments())
+		return
+	case "regenerate":
+		b.handleRegenerate(userID)
+		return
+	case "status":
+		b.sendStatus(userID)
+		return
+	case "angle":
+		b.handleAngleCommand(userID, msg.CommandArguments())
+		return
+	case "topics":
+		b.handleMoreTopics(userID)
+		return
+	case "favorites":
+		b.sendFavorites(userID)
+		return
+	case "expressive":
+		b.handleExpressiveCommand(userID)
+		return
+	case "myshow":
+		b.sendShowProfile(userID)
+		return
+	case "settings":
+		b.sendSettings(userID)
+		return
+	case "length":
+		b.sendLengths(userID)
+		return
+	case "format":
+		b.sendFormats(userID)
+		return
+	case "quality":
+		b.sendQualities(userID)
+		return
+	case "voice":
+		b.sendVoices(userID)
+		return
+	case "language":
+		b.handleLanguageCommand(userID, msg.CommandArguments())
+		return
+	case "speed":
+		b.handleSpeedCommand(userID, msg.CommandArguments())
+		return
+	case "style":
+		b.handleStyleCommand(userID, msg.CommandArguments())
+		return
+	case "feedback":
+		b.handleFeedback(userID, msg.From, msg.CommandArguments())
+		return
+	case "config":
+		b.handleConfigCommand(userID, msg.From)
+		return
+	case "broadcast":
+		// Non-admins fall through, as for any unknown command.
+		if msg.From != nil && b.isAdmin(msg.From.ID) {
+			b.handleBroadcast(userID, msg.CommandArguments())
+			return
+		}
 	}
 
-	switch state.WaitingFor {
-	case StateInitial:
-		b.sendCategories(userID)
+	if b.step(userID, flowInput{Text: msg.Text}) {
+		return
+	}
+	b.mu.Lock()
+	editing := state.WaitingFor == StateEditScript
+	b.mu.Unlock()
+	if editing {
+		b.handleScriptEdit(userID, msg.Text)
 	}
 }
 
 func (b *Bot) handleCallback(query *tgbotapi.CallbackQuery) {
-	userID := query.Message.Chat.ID
+	userID, ok := callbackChatID(query)
+	if !ok {
+		b.answerCallback(query.ID, "")
+		return
+	}
+	if query.From != nil && !b.isAllowed(query.From.ID) {
+		b.answerCallback(query.ID, b.localized(userID, msgUnauthorized))
+		return
+	}
+	if !b.limiter.allow(userID, time.Now()) {
+		b.answerCallback(query.ID, b.localized(userID, msgRateLimited))
+		return
+	}
 	data := query.Data
+	if b.isStaleButton(userID, data) {
+		b.answerCallback(query.ID, b.localized(userID, msgStaleButton))
+		return
+	}
+	if strings.HasPrefix(data, ratePrefix) {
+		b.answerCallback(query.ID, b.handleRating(userID, strings.TrimPrefix(data, ratePrefix)))
+		return
+	}
+	// Answer right away: generation can take longer than Telegram waits,
+	// and nothing below answers the query again.
+	b.answerCallback(query.ID, "")
 
-	state := b.getState(userID)
-
-	switch state.WaitingFor {
-	case StateCategory:
-		b.handleCategorySelection(userID, data)
-	case StateTopic:
-		b.handleTopicSelection(userID, data)
+	if b.step(userID, flowInput{Data: data}) {
+		return
+	}
+	if strings.HasPrefix(data, anglePrefix) {
+		b.handleAngleSelection(userID, strings.TrimPrefix(data, anglePrefix))
+		return
+	}
+	if strings.HasPrefix(data, pagePrefix) {
+		if query.Message != nil {
+			b.handlePage(userID, query.Message.MessageID, strings.TrimPrefix(data, pagePrefix))
+		}
+		return
+	}
+	if data == moreTopicsData {
+		b.handleMoreTopics(userID)
+		return
+	}
+	if data == backData {
+		b.handleBack(userID)
+		return
+	}
+	if strings.HasPrefix(data, favPrefix) {
+		b.handleFavoriteCallback(userID, strings.TrimPrefix(data, favPrefix))
+		return
+	}
+	if strings.HasPrefix(data, settingsPrefix) {
+		b.handleSettingsCallback(userID, strings.TrimPrefix(data, settingsPrefix))
+		return
+	}
+	if strings.HasPrefix(data, showPrefix) {
+		b.handleShowCallback(userID, strings.TrimPrefix(data, showPrefix))
+		return
+	}
+	if strings.HasPrefix(data, replayPrefix) {
+		b.handleReplayAudio(userID, strings.TrimPrefix(data, replayPrefix))
+		return
+	}
+	if strings.HasPrefix(data, lengthPrefix) {
+		b.handleLengthSelection(userID, strings.TrimPrefix(data, lengthPrefix))
+		return
+	}
+	if strings.HasPrefix(data, formatPrefix) {
+		b.handleFormatSelection(userID, strings.TrimPrefix(data, formatPrefix))
+		return
+	}
+	if strings.HasPrefix(data, voicePrefix) {
+		b.handleVoiceSelection(userID, strings.TrimPrefix(data, voicePrefix))
+		return
+	}
+	if _, action, ok := parseReviewData(data); ok {
+		b.handleReviewCallback(userID, action)
+		return
+	}
+	if strings.HasPrefix(data, qualityPrefix) {
+		b.handleQualitySelection(userID, strings.TrimPrefix(data, qualityPrefix))
+		return
+	}
+	if strings.HasPrefix(data, speedPrefix) {
+		b.setSpeed(userID, strings.TrimPrefix(data, speedPrefix))
+		return
+	}
+	if strings.HasPrefix(data, languagePrefix) {
+		b.handleLanguageSelection(userID, strings.TrimPrefix(data, languagePrefix))
+		return
+	}
+	if strings.HasPrefix(data, stylePrefix) {
+		b.setStyle(userID, strings.TrimPrefix(data, stylePrefix))
+		return
 	}
+	if strings.HasPrefix(data, consentPrefix) {
+		b.handleConsentCallback(userID, strings.TrimPrefix(data, consentPrefix))
+		return
+	}
+	if strings.HasPrefix(data, retryPrefix) {
+		b.handleRetry(userID, strings.TrimPrefix(data, retryPrefix))
+	}
+}
 
-	b.tg.Send(tgbotapi.NewCallback(query.ID, ""))
+// callbackChatID returns the chat a callback came from. Callbacks on inline
+// messages carry no Message, so the sender's private chat is used instead.
+func callbackChatID(query *tgbotapi.CallbackQuery) (int64, bool) {
+	if query.Message != nil && query.Message.Chat != nil {
+		return query.Message.Chat.ID, true
+	}
+	if query.From != nil {
+		return query.From.ID, true
+	}
+	return 0, false
 }
 
-func (b *Bot) sendCategories(userID int64) {
-	categories := []string{"Auto", "Health", "Travel", "ML", "Media"}
+// categoryPrefix marks category buttons' callback data.
+const categoryPrefix = "cat:"
+
+// maxCallbackData is Telegram's callback data limit, in bytes.
+const maxCallbackData = 64
+
+// categoryKeyboard shows page of the configured categories.
+func (b *Bot) categoryKeyboard(page int) tgbotapi.InlineKeyboardMarkup {
 	var buttons []tgbotapi.InlineKeyboardButton
-	for _, cat := range categories {
-		buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(cat, cat))
+	for _, cat := range b.cfg.Categories {
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(cat, categoryPrefix+cat))
 	}
 
-	msg := tgbotapi.NewMessage(userID, "Choose podcast category:")
-	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
-		tgbotapi.NewInlineKeyboardRow(buttons...),
-	)
-
-	b.mu.Lock()
-	b.states[userID].WaitingFor = StateCategory
-	b.mu.Unlock()
+	// A handful of categories fit on one row; longer lists wrap by three.
+	perRow := 3
+	if len(buttons) <= 5 {
+		perRow = len(buttons)
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(pageRows(buttons, perRow, page)...)
+}
 
-	b.tg.Send(msg)
+func (b *Bot) isCategory(name string) bool {
+	return b.cfg.isCategory(name)
 }
 
-func (b *Bot) handleCategorySelection(userID int64, category string) {
-	b.mu.Lock()
+// generateTopics asks the model for topics in the user's current category,
+// steered by their angle if one is set.
+func (b *Bot) generateTopics(userID int64) {
 	st := b.getState(userID)
-	st.Category = category
+	b.mu.Lock()
 	st.WaitingFor = StateTopic
+	category, angle := st.Category, st.Angle
+	avoid := recentTopics(st.SuggestedTopics)
 	b.mu.Unlock()
 
-	ctx := context.Background()
-	prompt := fmt.Sprintf("Generate 5 podcast topics about %s. Return as comma-separated list.", category)
-	resp, err := b.ai.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
-		Model:    openai.GPT4o,
-		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: prompt}},
-	})
+	ctx, done := b.startJob(userID, msgJobTopics)
+	defer done()
+	stopTyping := b.showChatAction(ctx, userID, tgbotapi.ChatTyping)
+	reply, err := b.chatJSON(ctx, userID, "", topicsPrompt(b.cfg.NumTopics, category, angle, b.profile(userID).languageName(), avoid))
+	stopTyping()
+	if ctx.Err() != nil {
+		return // cancelled by /cancel or a newer request
+	}
 	if err != nil {
-		b.sendError(userID)
+		b.sendAIError(userID, err, stepTopics)
 		return
 	}
 
-	topics := splitTopics(resp.Choices[0].Message.Content)
+	topics := parseTopics(reply, b.cfg.NumTopics)
+	if !b.ifCurrent(ctx, func() {
+		st.Topics = topics
+		st.TopicsGen++
+		st.SuggestedTopics = append(st.SuggestedTopics, topics...)
+	}) {
+		return
+	}
+	b.metrics.topicsGenerated()
 	b.sendTopics(userID, topics)
 }
 
 func (b *Bot) sendTopics(userID int64, topics []string) {
-	var buttons []tgbotapi.InlineKeyboardButton
-	for _, topic := range topics {
-		buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(topic, topic))
+	if len(topics) == 0 {
+		b.sendError(userID, stepTopics)
+		return
 	}
 
-	msg := tgbotapi.NewMessage(userID, "Choose a specific topic:")
-	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
-		tgbotapi.NewInlineKeyboardRow(buttons[:3]...),
-		tgbotapi.NewInlineKeyboardRow(buttons[3:]...),
-	)
+	st := b.getState(userID)
+	b.mu.Lock()
+	messageID, gen := st.TopicsMessageID, st.TopicsGen
+	st.Page = 0
+	b.mu.Unlock()
+	markup := topicsMarkup(topics, gen, 0)
+	if messageID != 0 && b.editKeyboard(userID, messageID, markup) {
+		return
+	}
 
-	b.tg.Send(msg)
+	msg := tgbotapi.NewMessage(userID, b.localized(userID, msgChooseTopic))
+	msg.ReplyMarkup = markup
+	sent, err := b.tg.Send(msg)
+	if err != nil {
+		return
+	}
+	b.mu.Lock()
+	st.TopicsMessageID = sent.MessageID
+	b.mu.Unlock()
+}
+
+// topicsMarkup shows page of topics, with Back and More topics buttons.
+func topicsMarkup(topics []string, gen, page int) tgbotapi.InlineKeyboardMarkup {
+	markup := topicKeyboard(topics, gen, page)
+	markup.InlineKeyboard = append(markup.InlineKeyboard, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("⬅ Back", backData),
+		tgbotapi.NewInlineKeyboardButtonData("🔄 More topics", moreTopicsData),
+	))
+	return markup
+}
+
+// topicKeyboard lays a page of topics out in rows of up to three buttons.
+// Each button's data is the list's generation and the topic's index, so
+// long topics survive Telegram's 64-byte callback data limit intact.
+func topicKeyboard(topics []string, gen, page int) tgbotapi.InlineKeyboardMarkup {
+	var buttons []tgbotapi.InlineKeyboardButton
+	for i, topic := range topics {
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(topic, topicData(gen, i)))
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(pageRows(buttons, 3, page)...)
 }
 
 func (b *Bot) handleTopicSelection(userID int64, topic string) {
 	st := b.getState(userID)
 	b.mu.Lock()
 	st.Topic = topic
+	category, lengthKey := st.Category, st.Length
 	b.mu.Unlock()
 
-	ctx := context.Background()
-	prompt := fmt.Sprintf("Create a 2-minute podcast script about %s in %s category. Keep it under 400 words.", topic, st.Category)
-	resp, err := b.ai.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
-		Model:    openai.GPT4o,
-		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: prompt}},
-	})
+	ctx, done := b.startJob(userID, msgJobScript)
+	defer done()
+	clearProgress := b.sendProgress(userID, b.localized(userID, msgGenerating))
+	defer clearProgress()
+
+	length := b.scriptLength(lengthKey)
+	prompt := scriptPrompt(b.scriptTemplate(category), topic, category, length, b.profile(userID))
+	switch {
+	case b.cfg.DialogueMode:
+		prompt += dialoguePrompt
+	case b.cfg.SegmentedScripts:
+		prompt += segmentsPrompt
+	}
+	if !b.cfg.KeepStageDirections {
+		// Speaker tags and section headers are structure the bot parses, so
+		// the structured modes only rule out cues.
+		if b.cfg.DialogueMode || b.cfg.SegmentedScripts {
+			prompt += noCuesPrompt
+		} else {
+			prompt += noDirectionsPrompt
+		}
+	}
+	stopTyping := b.showChatAction(ctx, userID, tgbotapi.ChatTyping)
+	script, err := b.chat(ctx, userID, b.styleSystemPrompt(userID), prompt)
+	stopTyping()
+	if ctx.Err() != nil {
+		return // cancelled by /cancel or a newer request
+	}
 	if err != nil {
-		b.sendError(userID)
+		b.sendAIError(userID, err, stepScript)
 		return
 	}
 
-	script := resp.Choices[0].Message.Content
-	b.mu.Lock()
-	st.ScriptText = script
-	b.mu.Unlock()
+	var segs []Segment
+	if b.cfg.SegmentedScripts && !b.cfg.DialogueMode {
+		// An unstructured reply is still a usable script.
+		if segs = parseSegments(script); segs != nil {
+			script = joinSegments(segs)
+		}
+	}
+
+	if trimmed, cut := trimToWords(script, length.Words); cut {
+		b.log.Info("trimmed script", "user_id", userID, "max_words", length.Words)
+		// The cut drops later sections, so the structure no longer holds.
+		script, segs = trimmed, nil
+	}
+
+	title := b.generateTitle(ctx, userID, topic, script)
+	if !b.ifCurrent(ctx, func() {
+		st.ScriptText = script
+		st.Title = title
+		st.Segments = segs
+	}) {
+		return // cancelled by /cancel or a newer request
+	}
+
+	id, err := b.repo.SaveEpisode(ctx, userID, category, topic, script)
+	if err != nil {
+		b.log.Error("save episode", "user_id", userID, "err", err)
+	}
+	if !b.ifCurrent(ctx, func() { st.EpisodeID = id }) {
+		return // cancelled by /cancel or a newer request
+	}
 
-	b.generateAndSendAudio(userID, script)
+	if b.cfg.CoverArt {
+		b.sendCover(ctx, userID)
+		if ctx.Err() != nil {
+			return // cancelled by /cancel or a newer request
+		}
+	}
+	b.sendReview(userID)
 }
 
 func (b *Bot) handleTextRequest(userID int64) {
 	st := b.getState(userID)
+	b.mu.Lock()
+	script := st.displayScript()
+	title := st.Title
+	b.mu.Unlock()
 
-	if st.ScriptText == "" {
-		msg := tgbotapi.NewMessage(userID, "No script available. Please create a podcast first!")
+	if script == "" {
+		msg := tgbotapi.NewMessage(userID, b.localized(userID, msgNoScript))
 		b.tg.Send(msg)
 		return
 	}
-
-	script := st.ScriptText
-	maxLength := 4000
-	if len(script) > maxLength {
-		script = script[:maxLength] + "\n... [truncated]"
+	if title != "" {
+		script = "*" + title + "*\n\n" + script
 	}
 
-	msg := tgbotapi.NewMessage(userID, script)
-	msg.ParseMode = tgbotapi.ModeMarkdown
-	b.tg.Send(msg)
+	b.sendScript(userID, script)
 }
 
-func (b *Bot) generateAndSendAudio(userID int64, text string) {
-	ctx := context.Background()
+// sendScript sends the whole script, split into numbered parts when it
+// exceeds Telegram's length limit.
+func (b *Bot) sendScript(userID int64, script string) {
+	parts := splitText(script, maxMessageLen-partHeaderLen)
+	for i, part := range parts {
+		if len(parts) > 1 {
+			part = b.localizedf(userID, msgPart, i+1, len(parts)) + "\n\n" + part
+		}
+		b.sendMarkdown(userID, part)
+	}
+}
+
+func (b *Bot) generateAndSendAudio(ctx context.Context, userID int64, text string) {
+	profile := b.profile(userID)
+	lang := b.cfg.SpeechLang
+	if _, ok := speechLocales[lang]; ok && profile.Language != "" {
+		lang = profile.Language
+	}
+
+	if !b.cfg.KeepStageDirections {
+		text = stripStageDirections(text)
+	}
+	var turns []dialogueTurn
+	if b.cfg.DialogueMode {
+		if turns = parseDialogue(text); turns == nil {
+			b.log.Warn("script is not a two-host dialogue, using one voice", "user_id", userID)
+		}
+		text = stripSpeakerTags(text)
+	}
+
 	req := openai.CreateSpeechRequest{
-		Model: openai.TTSModel1,
-		Input: text,
-		Voice: openai.VoiceAlloy,
+		Model: b.speechModel(userID),
+		Input: normalizeForSpeech(text, lang),
+		Voice: profile.voice(),
+		Speed: profile.speed(),
+	}
+	if b.usesInstructionsModel(userID) {
+		// tts-1 and tts-1-hd ignore instructions, so switch to a model that
+		// follows them.
+		req.Model = openai.TTSModelGPT4oMini
+		req.Instructions = b.speechInstructions(userID)
 	}
 
-	resp, err := b.ai.CreateSpeech(ctx, req)
-	if err != nil {
-		b.sendError(userID)
-		return
+	enc, voice := b.audioEncoding(userID)
+	parts := speechParts(req.Input, req.Voice)
+	if turns != nil {
+		voices := []openai.SpeechVoice{req.Voice, b.secondVoice(req.Voice)}
+		parts = nil
+		for _, t := range turns {
+			parts = append(parts, speechParts(normalizeForSpeech(t.Text, lang), voices[t.Host])...)
+		}
 	}
-	defer resp.Close()
+	if len(parts) > 1 && b.ffmpeg == "" && !enc.Joinable {
+		// Without ffmpeg the parts can only be joined byte by byte.
+		b.log.Warn("ffmpeg not found on PATH, sending long script as mp3", "user_id", userID, "format", enc.Format)
+		enc, voice = audioEncodings["mp3"], false
+	}
+	req.ResponseFormat = enc.Format
+	ext := enc.Ext
 
-	audioData, err := io.ReadAll(resp)
+	stopRecording := b.showChatAction(ctx, userID, tgbotapi.ChatRecordVoice)
+	defer stopRecording()
+	f, err := b.speak(ctx, userID, req, parts, enc)
 	if err != nil {
-		b.sendError(userID)
+		if ctx.Err() == nil {
+			b.sendAIError(userID, err, stepAudio)
+		}
 		return
 	}
+	defer b.removeTempFile(f.Name())
+	defer f.Close()
+	if ctx.Err() != nil {
+		return // cancelled by /cancel or a newer request
+	}
+	if processed := b.processAudio(ctx, userID, f, enc); processed != f {
+		defer b.removeTempFile(processed.Name())
+		defer processed.Close()
+		f = processed
+	}
+	stopRecording()
+	if ctx.Err() != nil {
+		return // cancelled by /cancel or a newer request
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		b.sendError(userID, stepAudio)
+		return
+	}
+
+	file := tgbotapi.FileReader{Name: b.audioFilename(userID, ext), Reader: f}
+	caption := b.cfg.AudioCaption
+	if caption == "" {
+		caption = b.localized(userID, msgCaption)
+	}
+	var episodeID int64
+	b.mu.Lock()
+	if st := b.states[userID]; st != nil {
+		if st.Title != "" {
+			caption = "🎙 " + st.Title
+		}
+		episodeID = st.EpisodeID
+	}
+	b.mu.Unlock()
+	markup := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("⭐ Save topic", favPrefix+"save"),
+		),
+		ratingRow(episodeID),
+		styleRow(),
+	)
 
-	audioPath := fmt.Sprintf("%d.mp3", userID)
-	if err := os.WriteFile(audioPath, audioData, 0644); err != nil {
-		b.sendError(userID)
+	var out tgbotapi.Chattable
+	if voice {
+		voiceMsg := tgbotapi.NewVoice(userID, file)
+		voiceMsg.Caption = caption
+		voiceMsg.ReplyMarkup = markup
+		out = voiceMsg
+	} else {
+		audioMsg := tgbotapi.NewAudio(userID, file)
+		audioMsg.Caption = caption
+		audioMsg.ReplyMarkup = markup
+		out = audioMsg
+	}
+
+	if ctx.Err() != nil {
+		return // cancelled by /cancel or a newer request
+	}
+	sent, err := b.tg.Send(out)
+	if err != nil {
+		b.sendError(userID, stepAudio)
 		return
 	}
-	defer os.Remove(audioPath)
 
-	audioMsg := tgbotapi.NewAudio(userID, tgbotapi.FilePath(audioPath))
-	audioMsg.Caption = "Here's your podcast, enjoy!"
-	b.tg.Send(audioMsg)
+	b.metrics.podcastGenerated()
+	stored := b.storeAudio(ctx, userID, f, ext)
+	b.recordStats(userID, sent, req.Input, req.Speed)
+	b.recordEpisode(userID, sent, b.audioURL(userID, stored))
+	if b.cfg.SendScriptText {
+		b.handleTextRequest(userID)
+	}
 }
 
-func splitTopics(input string) []string {
-	return strings.Split(input, ",")
+func topicsPrompt(n int, category, angle, language string, avoid []string) string {
+	prompt := fmt.Sprintf("Generate %d podcast topics about %s in %s", n, category, language)
+	if angle != "" {
+		prompt += fmt.Sprintf(" from a %s angle", angle)
+	}
+	prompt += `. Reply with JSON of the form {"topics": ["...", "..."]}.`
+	if len(avoid) > 0 {
+		prompt += " Do not repeat any of these topics: " + strings.Join(avoid, "; ") + "."
+	}
+	return prompt
 }
 
-func (b *Bot) sendError(userID int64) {
-	msg := tgbotapi.NewMessage(userID, "Error generating content. Please try again.")
-	b.tg.Send(msg)
-	b.sendCategories(userID)
+func (b *Bot) sendRateLimited(userID int64) {
+	b.tg.Send(tgbotapi.NewMessage(userID, b.localized(userID, msgRateLimited)))
 }
@@ -0,0 +1,585 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// apiCall is a Bot API method called through MakeRequest.
+type apiCall struct {
+	endpoint string
+	params   tgbotapi.Params
+}
+
+// fakeSender records what the bot sends instead of calling Telegram.
+type fakeSender struct {
+	mu       sync.Mutex
+	sent     []tgbotapi.Chattable
+	requests []tgbotapi.Chattable
+	calls    []apiCall
+	lastID   int
+	// reject, when set, fails the sends it returns an error for, which
+	// are not recorded.
+	reject func(tgbotapi.Chattable) error
+	// updates, when set, is what long polling receives.
+	updates chan tgbotapi.Update
+}
+
+func (f *fakeSender) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.reject != nil {
+		if err := f.reject(c); err != nil {
+			return tgbotapi.Message{}, err
+		}
+	}
+	f.sent = append(f.sent, c)
+	f.lastID++
+	return tgbotapi.Message{MessageID: f.lastID, Chat: &tgbotapi.Chat{}}, nil
+}
+
+func (f *fakeSender) Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, c)
+	return &tgbotapi.APIResponse{Ok: true}, nil
+}
+
+func (f *fakeSender) MakeRequest(endpoint string, params tgbotapi.Params) (*tgbotapi.APIResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, apiCall{endpoint, params})
+	return &tgbotapi.APIResponse{Ok: true}, nil
+}
+
+func (f *fakeSender) GetUpdatesChan(tgbotapi.UpdateConfig) tgbotapi.UpdatesChannel {
+	if f.updates != nil {
+		return f.updates
+	}
+	return make(chan tgbotapi.Update)
+}
+
+func (f *fakeSender) StopReceivingUpdates() {}
+
+// HandleUpdate decodes a webhook request body, as tgbotapi does.
+func (f *fakeSender) HandleUpdate(r *http.Request) (*tgbotapi.Update, error) {
+	var u tgbotapi.Update
+	if err := json.NewDecoder(r.Body).Decode(&u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (f *fakeSender) GetMe() (tgbotapi.User, error) {
+	return tgbotapi.User{UserName: "test_bot"}, nil
+}
+
+// messages returns the sent text messages.
+func (f *fakeSender) messages() []tgbotapi.MessageConfig {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var msgs []tgbotapi.MessageConfig
+	for _, c := range f.sent {
+		if m, ok := c.(tgbotapi.MessageConfig); ok {
+			msgs = append(msgs, m)
+		}
+	}
+	return msgs
+}
+
+// texts returns the text of every sent message.
+func (f *fakeSender) texts() []string {
+	var texts []string
+	for _, m := range f.messages() {
+		texts = append(texts, m.Text)
+	}
+	return texts
+}
+
+// sentAudio reports whether any audio or voice message was sent.
+func (f *fakeSender) sentAudio() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, c := range f.sent {
+		switch c.(type) {
+		case tgbotapi.AudioConfig, tgbotapi.VoiceConfig:
+			return true
+		}
+	}
+	return false
+}
+
+// callbackAnswers returns the text of every answered callback query.
+func (f *fakeSender) callbackAnswers() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var answers []string
+	for _, c := range f.requests {
+		if cb, ok := c.(tgbotapi.CallbackConfig); ok {
+			answers = append(answers, cb.Text)
+		}
+	}
+	return answers
+}
+
+// fakeAI answers like MockAI, unless chat or speech is set, and records
+// the chat prompts it was sent.
+type fakeAI struct {
+	MockAI
+	chat   func(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error)
+	speech func(ctx context.Context, req openai.CreateSpeechRequest) (openai.RawResponse, error)
+
+	mu      sync.Mutex
+	prompts []string
+}
+
+func (f *fakeAI) CreateChatCompletion(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	f.mu.Lock()
+	f.prompts = append(f.prompts, req.Messages[len(req.Messages)-1].Content)
+	f.mu.Unlock()
+	if f.chat != nil {
+		return f.chat(ctx, req)
+	}
+	return f.MockAI.CreateChatCompletion(ctx, req)
+}
+
+func (f *fakeAI) CreateSpeech(ctx context.Context, req openai.CreateSpeechRequest) (openai.RawResponse, error) {
+	if f.speech != nil {
+		return f.speech(ctx, req)
+	}
+	return f.MockAI.CreateSpeech(ctx, req)
+}
+
+// prompted reports whether any chat prompt contained s.
+func (f *fakeAI) prompted(s string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, p := range f.prompts {
+		if strings.Contains(p, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// reply is a completion with a single choice saying content.
+func reply(content string) openai.ChatCompletionResponse {
+	return openai.ChatCompletionResponse{
+		Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Content: content}}},
+	}
+}
+
+func newTestBot(t *testing.T, cfg Config) (*Bot, *fakeSender, *fakeAI) {
+	t.Helper()
+	if cfg.OutputDir == "" {
+		cfg.OutputDir = t.TempDir()
+	}
+	tg, ai := &fakeSender{}, &fakeAI{}
+	b, err := New(tg, ai, cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	b.SetLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	return b, tg, ai
+}
+
+const testUser = 42
+
+func command(name string) tgbotapi.Update {
+	return commandWith(name, "")
+}
+
+// commandWith is /name followed by args.
+func commandWith(name, args string) tgbotapi.Update {
+	text := "/" + name
+	u := tgbotapi.Update{Message: &tgbotapi.Message{
+		Text:     text,
+		Chat:     &tgbotapi.Chat{ID: testUser},
+		From:     &tgbotapi.User{ID: testUser},
+		Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(text)}},
+	}}
+	if args != "" {
+		u.Message.Text += " " + args
+	}
+	return u
+}
+
+func text(s string) tgbotapi.Update {
+	return tgbotapi.Update{Message: &tgbotapi.Message{
+		Text: s,
+		Chat: &tgbotapi.Chat{ID: testUser},
+		From: &tgbotapi.User{ID: testUser},
+	}}
+}
+
+func tap(data string) tgbotapi.Update {
+	return tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+		ID:      "q",
+		Data:    data,
+		From:    &tgbotapi.User{ID: testUser},
+		Message: &tgbotapi.Message{MessageID: 1, Chat: &tgbotapi.Chat{ID: testUser}},
+	}}
+}
+
+// stateOf returns a copy of a user's state.
+func (b *Bot) stateOf(userID int64) UserState {
+	st := b.getState(userID)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return *st.clone()
+}
+
+func TestFlowHappyPath(t *testing.T) {
+	b, tg, ai := newTestBot(t, Config{})
+
+	b.handleUpdate(command("new"))
+	if st := b.stateOf(testUser); st.WaitingFor != StateCategory {
+		t.Fatalf("after /new waiting for %q, want %q", st.WaitingFor, StateCategory)
+	}
+	if got := tg.texts(); len(got) != 1 || got[0] != messages["en"][msgChooseCategory] {
+		t.Fatalf("after /new sent %q", got)
+	}
+
+	b.handleUpdate(tap(categoryPrefix + DefaultCategories[0]))
+	st := b.stateOf(testUser)
+	if st.WaitingFor != StateTopic || st.Category != DefaultCategories[0] {
+		t.Fatalf("after category tap: waiting for %q in %q", st.WaitingFor, st.Category)
+	}
+	if len(st.Topics) != len(mockTopics) {
+		t.Fatalf("got %d topics, want %d", len(st.Topics), len(mockTopics))
+	}
+
+	b.handleUpdate(tap(topicData(st.TopicsGen, 1)))
+	st = b.stateOf(testUser)
+	if st.Topic != mockTopics[1] {
+		t.Fatalf("topic = %q, want %q", st.Topic, mockTopics[1])
+	}
+	if st.WaitingFor != StateReviewScript || st.ScriptText == "" {
+		t.Fatalf("after topic tap: waiting for %q with script %q", st.WaitingFor, st.ScriptText)
+	}
+	if !ai.prompted(mockTopics[1]) {
+		t.Errorf("no prompt mentions the topic %q", mockTopics[1])
+	}
+}
+
+func TestFlowRejectsInvalidInput(t *testing.T) {
+	b, tg, _ := newTestBot(t, Config{})
+	b.handleUpdate(command("new"))
+
+	// A topic button while choosing a category is a stale tap.
+	b.handleUpdate(tap(topicData(0, 0)))
+	if got := tg.callbackAnswers(); len(got) != 1 || got[0] != messages["en"][msgStaleButton] {
+		t.Errorf("answers = %q, want the stale button notice", got)
+	}
+
+	// Typing instead of tapping a category changes nothing.
+	before := len(tg.sent)
+	b.handleUpdate(text("Science"))
+	if st := b.stateOf(testUser); st.WaitingFor != StateCategory || st.Category != "" {
+		t.Errorf("after text: waiting for %q in %q", st.WaitingFor, st.Category)
+	}
+	if len(tg.sent) != before {
+		t.Errorf("text while choosing a category sent %d messages", len(tg.sent)-before)
+	}
+
+	// Not a configured category.
+	b.handleUpdate(tap(categoryPrefix + "Nope"))
+	if st := b.stateOf(testUser); st.WaitingFor != StateCategory {
+		t.Errorf("unknown category moved to %q", st.WaitingFor)
+	}
+}
+
+// blockingSpeech makes speech wait for release, then succeed regardless of
+// its context, like a reply that arrives after the job was cancelled.
+// started is closed when the first call begins.
+func blockingSpeech(ai *fakeAI) (started, release chan struct{}) {
+	started, release = make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	ai.speech = func(context.Context, openai.CreateSpeechRequest) (openai.RawResponse, error) {
+		once.Do(func() { close(started) })
+		<-release
+		return openai.RawResponse{ReadCloser: io.NopCloser(bytes.NewReader(silentMP3(1)))}, nil
+	}
+	return started, release
+}
+
+func TestCancelledJobSendsNoAudio(t *testing.T) {
+	b, tg, ai := newTestBot(t, Config{})
+	started, release := blockingSpeech(ai)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.recordAudio(testUser, "A short script.")
+	}()
+	<-started
+	b.handleUpdate(command("cancel"))
+	close(release)
+	<-done
+
+	if tg.sentAudio() {
+		t.Error("audio of a cancelled job was sent")
+	}
+	if st := b.stateOf(testUser); st.WaitingFor != StateInitial {
+		t.Errorf("waiting for %q after /cancel, want %q", st.WaitingFor, StateInitial)
+	}
+	b.mu.Lock()
+	_, running := b.jobs[testUser]
+	b.mu.Unlock()
+	if running {
+		t.Error("cancelled job is still registered")
+	}
+}
+
+func TestSupersededJobSendsNoAudio(t *testing.T) {
+	b, tg, ai := newTestBot(t, Config{})
+	started, release := blockingSpeech(ai)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.recordAudio(testUser, "A short script.")
+	}()
+	<-started
+
+	// A newer job replaces the one waiting on speech.
+	_, finish := b.startJob(testUser, msgJobTopics)
+	close(release)
+	<-done
+	finish()
+
+	if tg.sentAudio() {
+		t.Error("audio of a superseded job was sent")
+	}
+}
+
+func TestStaleTopicsDoNotReachState(t *testing.T) {
+	b, tg, ai := newTestBot(t, Config{})
+	b.handleUpdate(command("new"))
+
+	started, release := make(chan struct{}), make(chan struct{})
+	ai.chat = func(context.Context, openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+		close(started)
+		<-release
+		return reply(`{"topics": ["Late topic"]}`), nil
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.handleUpdate(tap(categoryPrefix + DefaultCategories[0]))
+	}()
+	<-started
+	b.cancelJob(testUser)
+	close(release)
+	<-done
+
+	if st := b.stateOf(testUser); len(st.Topics) != 0 {
+		t.Errorf("cancelled job stored topics %q", st.Topics)
+	}
+	for _, m := range tg.messages() {
+		if m.Text == messages["en"][msgChooseTopic] {
+			t.Error("cancelled job sent its topics")
+		}
+	}
+}
+
+func TestCompletedJobDelivers(t *testing.T) {
+	b, tg, _ := newTestBot(t, Config{})
+	b.recordAudio(testUser, "A short script.")
+	if !tg.sentAudio() {
+		t.Error("no audio was sent")
+	}
+}
+
+// inlineTap is a tap on a button of an inline-mode message, which Telegram
+// sends without a Message.
+func inlineTap(data string) tgbotapi.Update {
+	u := tap(data)
+	u.CallbackQuery.Message = nil
+	u.CallbackQuery.InlineMessageID = "inline"
+	return u
+}
+
+func TestCallbackWithoutMessage(t *testing.T) {
+	b, tg, _ := newTestBot(t, Config{})
+	b.handleUpdate(command("new"))
+	b.handleUpdate(inlineTap(categoryPrefix + DefaultCategories[0]))
+
+	if st := b.stateOf(testUser); st.WaitingFor != StateTopic || st.Category != DefaultCategories[0] {
+		t.Errorf("after the tap waiting for %q in %q", st.WaitingFor, st.Category)
+	}
+	for _, m := range tg.messages() {
+		if m.ChatID != testUser {
+			t.Errorf("sent %q to chat %d, want the sender's chat", m.Text, m.ChatID)
+		}
+	}
+	if got := tg.callbackAnswers(); len(got) != 1 {
+		t.Errorf("answered %d times, want once", len(got))
+	}
+}
+
+func TestCallbackWithoutMessageOrSender(t *testing.T) {
+	b, tg, _ := newTestBot(t, Config{})
+	u := inlineTap(categoryPrefix + DefaultCategories[0])
+	u.CallbackQuery.From = nil
+	b.handleUpdate(u)
+
+	if got := tg.callbackAnswers(); len(got) != 1 || got[0] != "" {
+		t.Errorf("answers = %q, want one empty answer", got)
+	}
+	if len(tg.messages()) != 0 {
+		t.Errorf("sent %q with no chat to send to", tg.texts())
+	}
+}
+
+func TestNoButtonNeedsTheMessage(t *testing.T) {
+	for _, data := range []string{
+		categoryPrefix + DefaultCategories[0], topicData(0, 0), anglePrefix + "history",
+		pagePrefix + "1", moreTopicsData, backData, favPrefix + "go:abc", favPrefix + "del:abc",
+		settingsPrefix + "length", showPrefix + "voice", replayPrefix + "1", ratePrefix + "5",
+		reviewData(1, "approve"), reviewData(1, "edit"), consentPrefix + "yes", retryPrefix + stepTopics,
+		voicePrefix + "nova", lengthPrefix + LengthShort, formatPrefix + FormatVoice,
+		qualityPrefix + "hd", languagePrefix + "es", speedPrefix + "1.25", stylePrefix + "calm",
+		"unknown",
+	} {
+		t.Run(data, func(t *testing.T) {
+			b, tg, _ := newTestBot(t, Config{})
+			// Called directly, so a panic fails the test instead of being
+			// recovered.
+			b.handleCallback(inlineTap(data).CallbackQuery)
+			if len(tg.callbackAnswers()) == 0 {
+				t.Error("the tap was not answered")
+			}
+		})
+	}
+}
+
+func TestCallbackRouting(t *testing.T) {
+	toCategory := func(b *Bot) { b.handleUpdate(command("new")) }
+	toTopic := func(b *Bot) {
+		toCategory(b)
+		b.handleUpdate(tap(categoryPrefix + DefaultCategories[0]))
+	}
+	tests := []struct {
+		name  string
+		setup func(b *Bot)
+		data  func(b *Bot) string
+		check func(t *testing.T, b *Bot, tg *fakeSender)
+	}{
+		{
+			name:  "category",
+			setup: toCategory,
+			data:  func(*Bot) string { return categoryPrefix + DefaultCategories[0] },
+			check: func(t *testing.T, b *Bot, _ *fakeSender) {
+				if st := b.stateOf(testUser); st.WaitingFor != StateTopic || len(st.Topics) == 0 {
+					t.Errorf("waiting for %q with topics %q", st.WaitingFor, st.Topics)
+				}
+			},
+		},
+		{
+			name:  "topic",
+			setup: toTopic,
+			data:  func(b *Bot) string { return topicData(b.stateOf(testUser).TopicsGen, 0) },
+			check: func(t *testing.T, b *Bot, _ *fakeSender) {
+				if st := b.stateOf(testUser); st.Topic != mockTopics[0] || st.WaitingFor != StateReviewScript {
+					t.Errorf("waiting for %q with topic %q", st.WaitingFor, st.Topic)
+				}
+			},
+		},
+		{
+			name:  "back",
+			setup: toTopic,
+			data:  func(*Bot) string { return backData },
+			check: func(t *testing.T, b *Bot, _ *fakeSender) {
+				if st := b.stateOf(testUser); st.WaitingFor != StateCategory || st.Category != "" {
+					t.Errorf("waiting for %q in %q", st.WaitingFor, st.Category)
+				}
+			},
+		},
+		{
+			name: "angle",
+			setup: func(b *Bot) {
+				toTopic(b)
+				b.handleUpdate(command("angle"))
+			},
+			data: func(*Bot) string { return anglePrefix + anglePresets[1] },
+			check: func(t *testing.T, b *Bot, _ *fakeSender) {
+				if st := b.stateOf(testUser); st.Angle != anglePresets[1] || st.WaitingFor != StateTopic {
+					t.Errorf("waiting for %q with angle %q", st.WaitingFor, st.Angle)
+				}
+			},
+		},
+		{
+			name:  "length",
+			setup: toTopic,
+			data:  func(*Bot) string { return lengthPrefix + LengthShort },
+			check: func(t *testing.T, b *Bot, _ *fakeSender) {
+				if st := b.stateOf(testUser); st.Length != LengthShort || st.WaitingFor != StateTopic {
+					t.Errorf("waiting for %q with length %q", st.WaitingFor, st.Length)
+				}
+			},
+		},
+		{
+			name: "review",
+			setup: func(b *Bot) {
+				b.handleTopicSelection(testUser, "Lighthouses")
+			},
+			data: func(b *Bot) string { return reviewData(b.stateOf(testUser).ScriptGen, "approve") },
+			check: func(t *testing.T, _ *Bot, tg *fakeSender) {
+				if !tg.sentAudio() {
+					t.Error("approving did not record the audio")
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, tg, _ := newTestBot(t, Config{})
+			tt.setup(b)
+			b.handleUpdate(tap(tt.data(b)))
+			tt.check(t, b, tg)
+		})
+	}
+}
+
+func TestStaleCategoryTap(t *testing.T) {
+	b, tg, ai := newTestBot(t, Config{})
+	b.handleUpdate(command("new"))
+	b.handleUpdate(tap(categoryPrefix + DefaultCategories[0]))
+	before := b.stateOf(testUser)
+	prompts := len(ai.prompts)
+
+	// The categories message of this /new is still on screen.
+	b.handleUpdate(tap(categoryPrefix + DefaultCategories[1]))
+
+	after := b.stateOf(testUser)
+	if after.WaitingFor != StateTopic || after.Category != before.Category || after.TopicsGen != before.TopicsGen {
+		t.Errorf("a stale tap moved the state to %q in %q", after.WaitingFor, after.Category)
+	}
+	if len(ai.prompts) != prompts {
+		t.Error("a stale tap asked for topics")
+	}
+	if got := tg.callbackAnswers(); got[len(got)-1] != messages["en"][msgStaleButton] {
+		t.Errorf("answers = %q, want the stale button notice", got)
+	}
+}
+
+func TestNewRequiresClients(t *testing.T) {
+	if _, err := New(nil, &fakeAI{}, Config{}); !errors.Is(err, ErrNoTelegram) {
+		t.Errorf("New without Telegram = %v, want %v", err, ErrNoTelegram)
+	}
+	if _, err := New(&fakeSender{}, nil, Config{}); !errors.Is(err, ErrNoAI) {
+		t.Errorf("New without AI = %v, want %v", err, ErrNoAI)
+	}
+}
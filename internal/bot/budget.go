@@ -0,0 +1,122 @@
+package bot
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ErrBudgetExhausted is returned for OpenAI requests once the day's usage
+// budget is spent.
+var ErrBudgetExhausted = errors.New("bot: daily usage budget exhausted")
+
+// budgetFile holds the day's usage in Config.StateDir.
+const budgetFile = "budget.json"
+
+// budget caps the chat tokens and TTS characters used across all users in
+// a UTC day. A non-positive limit is unlimited.
+type budget struct {
+	tokenLimit, charLimit int
+	// path, if set, is where usage is saved so restarts keep it.
+	path string
+
+	mu    sync.Mutex
+	usage budgetUsage
+}
+
+type budgetUsage struct {
+	Day    string `json:"day"`
+	Tokens int    `json:"tokens"`
+	Chars  int    `json:"chars"`
+}
+
+// newBudget creates a budget, resuming the usage saved at path if any.
+func newBudget(tokenLimit, charLimit int, path string) (*budget, error) {
+	bg := &budget{tokenLimit: tokenLimit, charLimit: charLimit, path: path}
+	if path == "" {
+		return bg, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return bg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &bg.usage); err != nil {
+		return nil, err
+	}
+	return bg, nil
+}
+
+func budgetDay(now time.Time) string {
+	return now.UTC().Format("2006-01-02")
+}
+
+// rollOver starts a new day's usage at midnight UTC. The caller must hold
+// bg.mu.
+func (bg *budget) rollOver(now time.Time) {
+	if day := budgetDay(now); bg.usage.Day != day {
+		bg.usage = budgetUsage{Day: day}
+	}
+}
+
+// allow reports whether any budget is left at now.
+func (bg *budget) allow(now time.Time) bool {
+	if bg == nil {
+		return true
+	}
+	bg.mu.Lock()
+	defer bg.mu.Unlock()
+	bg.rollOver(now)
+	return (bg.tokenLimit <= 0 || bg.usage.Tokens < bg.tokenLimit) &&
+		(bg.charLimit <= 0 || bg.usage.Chars < bg.charLimit)
+}
+
+// spend records usage at now and saves it.
+func (bg *budget) spend(now time.Time, tokens, chars int) error {
+	if bg == nil {
+		return nil
+	}
+	bg.mu.Lock()
+	defer bg.mu.Unlock()
+	bg.rollOver(now)
+	bg.usage.Tokens += tokens
+	bg.usage.Chars += chars
+	return bg.save()
+}
+
+// save atomically replaces the usage file. The caller must hold bg.mu.
+func (bg *budget) save() error {
+	if bg.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(bg.usage)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(bg.path), "budget-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), bg.path)
+}
+
+// spendAI records OpenAI usage against the daily budget.
+func (b *Bot) spendAI(tokens, chars int) {
+	if err := b.budget.spend(time.Now(), tokens, chars); err != nil {
+		b.log.Error("save usage budget", "err", err)
+	}
+}
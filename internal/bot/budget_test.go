@@ -0,0 +1,84 @@
+package bot
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestBudgetExhausts(t *testing.T) {
+	now := time.Date(2026, 3, 1, 23, 59, 0, 0, time.UTC)
+	bg, err := newBudget(100, 50, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bg.spend(now, 99, 0)
+	if !bg.allow(now) {
+		t.Fatal("budget exhausted below its token limit")
+	}
+	bg.spend(now, 1, 0)
+	if bg.allow(now) {
+		t.Error("budget allowed requests at its token limit")
+	}
+	if !bg.allow(now.Add(time.Minute)) {
+		t.Error("budget did not reset at midnight UTC")
+	}
+	bg.spend(now.Add(time.Minute), 0, 50)
+	if bg.allow(now.Add(time.Minute)) {
+		t.Error("budget allowed requests at its character limit")
+	}
+}
+
+func TestBudgetSurvivesRestarts(t *testing.T) {
+	now := time.Now()
+	path := filepath.Join(t.TempDir(), budgetFile)
+	bg, err := newBudget(100, 0, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bg.spend(now, 100, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	resumed, err := newBudget(100, 0, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resumed.allow(now) {
+		t.Error("a restart reset the budget")
+	}
+}
+
+func TestBudgetCountsSpeechInRunes(t *testing.T) {
+	b, _, _ := newTestBot(t, Config{DailyCharBudget: 1000})
+	b.recordAudio(testUser, "ñandú 🎙")
+	b.budget.mu.Lock()
+	defer b.budget.mu.Unlock()
+	if got := b.budget.usage.Chars; got != 7 {
+		t.Errorf("spent %d characters, want 7", got)
+	}
+}
+
+func TestBudgetReached(t *testing.T) {
+	b, tg, ai := newTestBot(t, Config{DailyTokenBudget: 10})
+	calls := 0
+	ai.chat = func(context.Context, openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+		calls++
+		resp := reply(`{"topics": ["Lighthouses"]}`)
+		resp.Usage.TotalTokens = 10
+		return resp, nil
+	}
+	b.handleUpdate(command("new"))
+	b.handleUpdate(tap(categoryPrefix + DefaultCategories[0]))
+	b.handleUpdate(tap(topicData(b.stateOf(testUser).TopicsGen, 0)))
+
+	if calls != 1 {
+		t.Errorf("made %d chat calls, want 1 before the budget ran out", calls)
+	}
+	if got := tg.texts(); got[len(got)-1] != messages["en"][msgBudgetReached] {
+		t.Errorf("last message = %q, want the daily limit notice", got[len(got)-1])
+	}
+}
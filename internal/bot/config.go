@@ -0,0 +1,259 @@
+package bot
+
+import (
+	"strings"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// DefaultFilenameTemplate is used when Config.FilenameTemplate is empty.
+const DefaultFilenameTemplate = "{category} - {topic} ({date})"
+
+// DefaultScriptTemplate is used when Config.ScriptTemplate is empty.
+const DefaultScriptTemplate = "Create a {minutes}-minute podcast script about {topic} in {category} category. Keep it under {words} words."
+
+// DefaultCategories are offered when Config.Categories is empty.
+var DefaultCategories = []string{"Auto", "Health", "Travel", "ML", "Media"}
+
+// DefaultNumTopics is used when Config.NumTopics is zero.
+const DefaultNumTopics = 5
+
+// MaxNumTopics is the most topics Config.NumTopics may ask for.
+const MaxNumTopics = 10
+
+// DefaultWorkers is used when Config.Workers is not positive.
+const DefaultWorkers = 4
+
+// DefaultAITimeout is used when Config.AITimeout is not positive.
+const DefaultAITimeout = 60 * time.Second
+
+// DefaultSpeechLang is used when Config.SpeechLang is empty.
+const DefaultSpeechLang = "en"
+
+// Config holds optional bot settings. Zero values fall back to defaults.
+// Each field's env tag names the environment variable LoadConfigFromEnv in
+// cmd/podcaster reads it from.
+type Config struct {
+	// AdminIDs are Telegram user IDs allowed to run operator commands.
+	AdminIDs []int64 `env:"ADMIN_IDS"`
+	// AllowedIDs, if set, are the only Telegram user IDs, besides admins,
+	// allowed to use the bot.
+	AllowedIDs []int64 `env:"ALLOWED_USER_IDS"`
+
+	// StateDir, if set, stores user state as JSON files so it survives
+	// restarts.
+	StateDir string `env:"STATE_DIR"`
+
+	// Workers is how many updates are handled concurrently. Defaults to
+	// DefaultWorkers.
+	Workers int `env:"WORKERS"`
+
+	// RateLimit is how many messages and button taps a user may send per
+	// minute. Zero disables rate limiting.
+	RateLimit int `env:"RATE_LIMIT_PER_MINUTE"`
+
+	// Categories are the podcast categories users choose from. Defaults to
+	// DefaultCategories.
+	Categories []string `env:"CATEGORIES"`
+	// NumTopics is how many topics are suggested at a time, from 1 to
+	// MaxNumTopics. Zero selects DefaultNumTopics.
+	NumTopics int `env:"NUM_TOPICS"`
+
+	// ScriptTemplate is the script prompt, with {topic}, {category},
+	// {minutes} and {words} placeholders; {topic} and {words} are required.
+	// CategoryTemplates override it per category, keyed by category name.
+	// Defaults to DefaultScriptTemplate.
+	ScriptTemplate    string            `env:"SCRIPT_PROMPT_TEMPLATE"`
+	CategoryTemplates map[string]string `env:"CATEGORY_PROMPT_TEMPLATES"`
+
+	// MetricsAddr, if set, serves Prometheus metrics at /metrics on this
+	// address, e.g. ":9090".
+	MetricsAddr string `env:"METRICS_ADDR"`
+	// HealthAddr, if set, serves /healthz (liveness) and /readyz (ready
+	// once Telegram accepted the bot) on this address, e.g. ":8081".
+	HealthAddr string `env:"HEALTH_ADDR"`
+
+	// ChatModel generates topics and scripts. Defaults to openai.GPT4o.
+	ChatModel string `env:"OPENAI_CHAT_MODEL"`
+
+	// AITimeout bounds each OpenAI request. Defaults to DefaultAITimeout.
+	AITimeout time.Duration `env:"OPENAI_TIMEOUT"`
+	// MaxConcurrentAI caps OpenAI requests in flight across all users; the
+	// rest wait their turn. Zero means no limit.
+	MaxConcurrentAI int `env:"OPENAI_MAX_CONCURRENT"`
+
+	// AudioCaption, if set, replaces the localized caption of delivered
+	// audio for episodes without a title.
+	AudioCaption string `env:"AUDIO_CAPTION"`
+
+	// FilenameTemplate names delivered audio files. Supported placeholders
+	// are {category}, {topic} and {date} (YYYY-MM-DD). Defaults to
+	// DefaultFilenameTemplate.
+	FilenameTemplate string `env:"AUDIO_FILENAME_TEMPLATE"`
+	// SpeechLang selects how numbers and abbreviations are expanded before
+	// synthesis. Unsupported values such as "off" disable normalization.
+	// Defaults to DefaultSpeechLang.
+	SpeechLang string `env:"SPEECH_LANG"`
+
+	// HistoryMaxAge and HistoryMaxEpisodes bound each user's episode
+	// history. A zero age never expires episodes; a zero count keeps
+	// DefaultHistorySize episodes in memory and every stored one. The
+	// limits also prune the repository and the files in AudioDir.
+	HistoryMaxAge      time.Duration `env:"HISTORY_MAX_AGE"`
+	HistoryMaxEpisodes int           `env:"HISTORY_MAX_EPISODES"`
+	// HistoryExportDir, if set, receives pruned episodes as JSON files
+	// before they are removed from history.
+	HistoryExportDir string `env:"HISTORY_EXPORT_DIR"`
+
+	// SpeechInstructions are style instructions passed to the TTS model,
+	// e.g. "Speak warmly, like a late-night radio host".
+	SpeechInstructions string `env:"TTS_INSTRUCTIONS"`
+	// RequireInstructionsConsent only applies SpeechInstructions for users
+	// who accepted the expressive narration disclaimer.
+	RequireInstructionsConsent bool `env:"TTS_INSTRUCTIONS_CONSENT"`
+
+	// ScriptStyle is the default tone for scripts, sent to the model as a
+	// system message, e.g. "Use a calm documentary tone.". A user's /style
+	// overrides it.
+	ScriptStyle string `env:"SCRIPT_STYLE"`
+
+	// DialogueMode writes scripts as a conversation between two hosts, read
+	// by the user's voice and DialogueVoice (default: another voice). It
+	// takes precedence over SegmentedScripts. Scripts without both hosts'
+	// tags are read by one voice.
+	DialogueMode  bool   `env:"DIALOGUE_MODE"`
+	DialogueVoice string `env:"DIALOGUE_VOICE"`
+
+	// SendScriptText sends the script, as /text would, right after every
+	// podcast's audio.
+	SendScriptText bool `env:"SEND_SCRIPT_TEXT"`
+
+	// MaxScriptWords caps the words asked for in every script; longer
+	// replies are trimmed at a sentence boundary. Zero uses each /length
+	// option's own count (400 for Medium).
+	MaxScriptWords int `env:"MAX_SCRIPT_WORDS"`
+
+	// SegmentedScripts asks for scripts with an intro, main points and an
+	// outro, shown with section headers by /text.
+	SegmentedScripts bool `env:"SEGMENTED_SCRIPTS"`
+
+	// KeepStageDirections disables stripping [bracketed] asides and
+	// parenthetical cues such as "(laughs)" from scripts before synthesis.
+	KeepStageDirections bool `env:"KEEP_STAGE_DIRECTIONS"`
+
+	// TelegramWebhookURL, if set, receives updates from Telegram instead of
+	// long polling. The bot registers it with Telegram and listens on
+	// TelegramWebhookAddr (default DefaultTelegramWebhookAddr), behind a
+	// TLS-terminating proxy, at its path. Requests must carry
+	// TelegramWebhookSecret in the X-Telegram-Bot-Api-Secret-Token header;
+	// when it is empty a random secret is registered at every start.
+	TelegramWebhookURL    string `env:"TELEGRAM_WEBHOOK_URL"`
+	TelegramWebhookAddr   string `env:"TELEGRAM_WEBHOOK_ADDR"`
+	TelegramWebhookSecret string `env:"TELEGRAM_WEBHOOK_SECRET"`
+
+	// WebhookURL receives a signed JSON POST for every delivered episode.
+	// WebhookSecret is the HMAC-SHA256 key used for the signature.
+	WebhookURL    string `env:"WEBHOOK_URL"`
+	WebhookSecret string `env:"WEBHOOK_SECRET"`
+
+	// DatabasePath, if set, stores every generated script in a SQLite
+	// database at this path.
+	DatabasePath string `env:"DATABASE_PATH"`
+
+	// AudioFormat is the TTS output format: mp3, opus, aac or flac. Opus
+	// audio is delivered as voice messages. Defaults to DefaultAudioFormat.
+	AudioFormat string `env:"AUDIO_FORMAT"`
+
+	// BackgroundMusic, if set, is an audio file looped quietly under the
+	// narration at MusicVolume (default DefaultMusicVolume). Mixing needs
+	// ffmpeg on PATH and is skipped without it.
+	BackgroundMusic string  `env:"BACKGROUND_MUSIC"`
+	MusicVolume     float64 `env:"MUSIC_VOLUME"`
+	// IntroAudio and OutroAudio, if set, are clips played before and after
+	// every podcast. They are transcoded to match the speech, so any format
+	// ffmpeg reads will do.
+	IntroAudio string `env:"INTRO_AUDIO"`
+	OutroAudio string `env:"OUTRO_AUDIO"`
+
+	// CoverArt generates a DALL·E cover image for every episode, sent
+	// before the script and shown in the feed. It adds cost and latency.
+	CoverArt bool `env:"COVER_ART"`
+
+	// DailyTokenBudget and DailyCharBudget cap the chat tokens and TTS
+	// characters used across all users per UTC day; once either is spent,
+	// generation is refused until midnight UTC. Zero means unlimited. Usage
+	// is kept in StateDir, if set, so it survives restarts.
+	DailyTokenBudget int `env:"DAILY_TOKEN_BUDGET"`
+	DailyCharBudget  int `env:"DAILY_CHAR_BUDGET"`
+
+	// OutputDir holds audio while it is generated and uploaded. Defaults to
+	// the system temp directory. Files a crashed run left behind are
+	// removed on startup.
+	OutputDir string `env:"OUTPUT_DIR"`
+
+	// AudioDir, if set, keeps a copy of every delivered episode's audio.
+	AudioDir string `env:"AUDIO_DIR"`
+	// FeedAddr, if set, serves every user a private RSS podcast feed of
+	// their stored episodes on this address. It requires AudioDir and
+	// DatabasePath. FeedURL is the public base URL the feeds and audio are
+	// reachable at. FeedSecret keys the tokens in feed URLs; changing it
+	// revokes every feed URL handed out. FeedImageURL, if set, is the
+	// channel artwork podcast apps show for every feed.
+	FeedAddr     string `env:"FEED_ADDR"`
+	FeedURL      string `env:"FEED_URL"`
+	FeedSecret   string `env:"FEED_SECRET"`
+	FeedImageURL string `env:"FEED_IMAGE_URL"`
+}
+
+func (c Config) withDefaults() Config {
+	if c.Workers <= 0 {
+		c.Workers = DefaultWorkers
+	}
+	if len(c.Categories) == 0 {
+		c.Categories = DefaultCategories
+	}
+	if c.NumTopics == 0 {
+		c.NumTopics = DefaultNumTopics
+	}
+	if c.ScriptTemplate == "" {
+		c.ScriptTemplate = DefaultScriptTemplate
+	}
+	if c.ChatModel == "" {
+		c.ChatModel = openai.GPT4o
+	}
+	if c.AITimeout <= 0 {
+		c.AITimeout = DefaultAITimeout
+	}
+	if c.FilenameTemplate == "" {
+		c.FilenameTemplate = DefaultFilenameTemplate
+	}
+	if c.SpeechLang == "" {
+		c.SpeechLang = DefaultSpeechLang
+	}
+	if c.TelegramWebhookAddr == "" {
+		c.TelegramWebhookAddr = DefaultTelegramWebhookAddr
+	}
+	if c.MusicVolume <= 0 {
+		c.MusicVolume = DefaultMusicVolume
+	}
+	c.AudioFormat = strings.ToLower(c.AudioFormat)
+	if c.AudioFormat == "" {
+		c.AudioFormat = DefaultAudioFormat
+	}
+	return c
+}
+
+// processesAudio reports whether speech is post-processed with ffmpeg.
+func (c Config) processesAudio() bool {
+	return c.BackgroundMusic != "" || c.IntroAudio != "" || c.OutroAudio != ""
+}
+
+func (c Config) isCategory(name string) bool {
+	for _, cat := range c.Categories {
+		if cat == name {
+			return true
+		}
+	}
+	return false
+}
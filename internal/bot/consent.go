@@ -0,0 +1,115 @@
+package bot
+
+import (
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const consentPrefix = "consent:"
+
+// speechInstructions returns the TTS style instructions for a user, or ""
+// when none apply or the user has not opted in.
+func (b *Bot) speechInstructions(userID int64) string {
+	instr := b.rawInstructions(userID)
+	if instr == "" || !b.cfg.RequireInstructionsConsent {
+		return instr
+	}
+
+	st := b.getState(userID)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !st.Prefs.InstructionsConsent {
+		return ""
+	}
+	return instr
+}
+
+// rawInstructions combines the configured instructions with those of the
+// user's show style, ignoring consent.
+func (b *Bot) rawInstructions(userID int64) string {
+	var parts []string
+	if b.cfg.SpeechInstructions != "" {
+		parts = append(parts, b.cfg.SpeechInstructions)
+	}
+	if s, ok := findStyle(b.profile(userID).Style); ok {
+		parts = append(parts, s.Instructions)
+	}
+	return strings.Join(parts, " ")
+}
+
+// usesInstructionsModel reports whether the user's podcasts are narrated
+// by the instruction-following TTS model. Only the operator's
+// SpeechInstructions switch models; a show style alone keeps the user's
+// /quality choice, since tts-1 and tts-1-hd ignore instructions.
+func (b *Bot) usesInstructionsModel(userID int64) bool {
+	return b.expressiveNarration(userID) && b.speechInstructions(userID) != ""
+}
+
+// expressiveNarration reports whether the user's podcasts would carry
+// instructions, from SpeechInstructions or their show style, once they
+// consent.
+func (b *Bot) expressiveNarration(userID int64) bool {
+	return b.cfg.SpeechInstructions != "" && b.rawInstructions(userID) != ""
+}
+
+// askConsentFirst shows the expressive narration disclaimer before the
+// first podcast that would use instructions, and reports whether it did.
+// The approved script then waits for the answer instead of being narrated
+// without it; handleConsentCallback records it.
+func (b *Bot) askConsentFirst(userID int64) bool {
+	if !b.cfg.RequireInstructionsConsent || !b.expressiveNarration(userID) {
+		return false
+	}
+
+	st := b.getState(userID)
+	b.mu.Lock()
+	asked := st.Prefs.ConsentAsked
+	if !asked {
+		st.Prefs.ConsentAsked = true
+		st.AudioAwaitsConsent = true
+	}
+	b.mu.Unlock()
+	if asked {
+		return false
+	}
+	b.sendConsent(userID)
+	return true
+}
+
+func (b *Bot) sendConsent(userID int64) {
+	msg := tgbotapi.NewMessage(userID, b.localized(userID, msgConsent))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ I understand, enable", consentPrefix+"yes"),
+			tgbotapi.NewInlineKeyboardButtonData("No thanks", consentPrefix+"no"),
+		),
+	)
+	b.tg.Send(msg)
+}
+
+func (b *Bot) handleConsentCallback(userID int64, answer string) {
+	st := b.getState(userID)
+	b.mu.Lock()
+	st.Prefs.InstructionsConsent = answer == "yes"
+	waiting, script := st.AudioAwaitsConsent, st.ScriptText
+	st.AudioAwaitsConsent = false
+	b.mu.Unlock()
+
+	text := b.localized(userID, msgExpressiveOff)
+	if answer == "yes" {
+		text = b.localized(userID, msgExpressiveOn)
+	}
+	b.tg.Send(tgbotapi.NewMessage(userID, text))
+	if waiting && script != "" {
+		b.recordAudio(userID, script)
+	}
+}
+
+func (b *Bot) handleExpressiveCommand(userID int64) {
+	if !b.cfg.RequireInstructionsConsent {
+		b.tg.Send(tgbotapi.NewMessage(userID, b.localized(userID, msgExpressiveNoOptIn)))
+		return
+	}
+	b.sendConsent(userID)
+}
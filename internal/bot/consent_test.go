@@ -0,0 +1,110 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// recordSpeech makes speech succeed and returns a func listing the
+// requests made so far.
+func recordSpeech(ai *fakeAI) func() []openai.CreateSpeechRequest {
+	var (
+		mu   sync.Mutex
+		reqs []openai.CreateSpeechRequest
+	)
+	ai.speech = func(_ context.Context, req openai.CreateSpeechRequest) (openai.RawResponse, error) {
+		mu.Lock()
+		reqs = append(reqs, req)
+		mu.Unlock()
+		return openai.RawResponse{ReadCloser: io.NopCloser(bytes.NewReader(silentMP3(1)))}, nil
+	}
+	return func() []openai.CreateSpeechRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]openai.CreateSpeechRequest(nil), reqs...)
+	}
+}
+
+func TestConsentIsAskedBeforeTheFirstPodcast(t *testing.T) {
+	for _, answer := range []string{"yes", "no"} {
+		t.Run(answer, func(t *testing.T) {
+			b, tg, ai := newTestBot(t, Config{SpeechInstructions: "Speak warmly.", RequireInstructionsConsent: true})
+			speech := recordSpeech(ai)
+			b.handleTopicSelection(testUser, "Lighthouses")
+			b.handleUpdate(b.reviewTap("approve"))
+
+			if !slices.Contains(tg.texts(), messages["en"][msgConsent]) {
+				t.Fatalf("approving sent %q, want the consent disclaimer", tg.texts())
+			}
+			if got := speech(); len(got) != 0 {
+				t.Fatalf("narrated %d parts before the answer", len(got))
+			}
+
+			b.handleUpdate(tap(consentPrefix + answer))
+			reqs := speech()
+			if len(reqs) == 0 || !tg.sentAudio() {
+				t.Fatalf("after %q: %d speech requests, audio sent %v; want the waiting podcast", answer, len(reqs), tg.sentAudio())
+			}
+			if got, want := reqs[0].Instructions != "", answer == "yes"; got != want {
+				t.Errorf("after %q narrated with instructions %q", answer, reqs[0].Instructions)
+			}
+
+			// Later podcasts go ahead without asking again.
+			before := len(tg.texts())
+			b.handleTopicSelection(testUser, "Tides")
+			b.handleUpdate(b.reviewTap("approve"))
+			if slices.Contains(tg.texts()[before:], messages["en"][msgConsent]) || len(speech()) == len(reqs) {
+				t.Errorf("second podcast sent %q and was not narrated", tg.texts()[before:])
+			}
+		})
+	}
+}
+
+func TestConsentCoversStylePresets(t *testing.T) {
+	b, _, ai := newTestBot(t, Config{SpeechInstructions: "Speak warmly.", RequireInstructionsConsent: true})
+	speech := recordSpeech(ai)
+	b.handleUpdate(tap(stylePrefix + "News"))
+	b.handleTopicSelection(testUser, "Lighthouses")
+	b.handleUpdate(b.reviewTap("approve"))
+	if got := speech(); len(got) != 0 {
+		t.Fatalf("a show style narrated %d parts before consent", len(got))
+	}
+	b.handleUpdate(tap(consentPrefix + "yes"))
+	reqs := speech()
+	news, _ := findStyle("News")
+	if len(reqs) == 0 || !strings.Contains(reqs[0].Instructions, news.Instructions) {
+		t.Errorf("narrated with %+v, want the style's instructions added", reqs)
+	}
+}
+
+func TestNoConsentWithoutInstructions(t *testing.T) {
+	b, tg, ai := newTestBot(t, Config{RequireInstructionsConsent: true})
+	speech := recordSpeech(ai)
+	b.handleTopicSelection(testUser, "Lighthouses")
+	b.handleUpdate(b.reviewTap("approve"))
+	if slices.Contains(tg.texts(), messages["en"][msgConsent]) || len(speech()) == 0 {
+		t.Errorf("sent %q and %d speech requests, want narration without a disclaimer", tg.texts(), len(speech()))
+	}
+}
+
+func TestLateConsentSkipsAbandonedScript(t *testing.T) {
+	b, tg, ai := newTestBot(t, Config{SpeechInstructions: "Speak warmly.", RequireInstructionsConsent: true})
+	speech := recordSpeech(ai)
+	b.handleTopicSelection(testUser, "Lighthouses")
+	b.handleUpdate(b.reviewTap("approve"))
+	b.handleUpdate(command("new"))
+	b.handleUpdate(tap(consentPrefix + "yes"))
+	if len(speech()) != 0 || tg.sentAudio() {
+		t.Errorf("consent after /new narrated the abandoned script")
+	}
+	if !b.stateOf(testUser).Prefs.InstructionsConsent {
+		t.Error("the answer was not recorded")
+	}
+}
@@ -0,0 +1,97 @@
+package bot
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// coverExt is the extension of stored cover images; the image API returns
+// PNG.
+const coverExt = ".png"
+
+func coverPrompt(category, topic, title string) string {
+	return fmt.Sprintf("Square podcast cover art for an episode titled %q about %s, in the %s category. "+
+		"Bold, simple illustration with no text or lettering.", title, topic, category)
+}
+
+// sendCover generates cover art for the user's episode and sends it as a
+// photo. Failures are only logged: the cover is a bonus and the podcast
+// works without it.
+func (b *Bot) sendCover(ctx context.Context, userID int64) {
+	st := b.getState(userID)
+	b.mu.Lock()
+	category, topic, title, id := st.Category, st.Topic, st.Title, st.EpisodeID
+	b.mu.Unlock()
+
+	img, err := b.generateCover(ctx, coverPrompt(category, topic, title))
+	if ctx.Err() != nil {
+		return // cancelled by /cancel or a newer request
+	}
+	if err != nil {
+		b.metrics.aiError(err)
+		b.log.Error("generate cover", "user_id", userID, "err", err)
+		return
+	}
+
+	photo := tgbotapi.NewPhoto(userID, tgbotapi.FileBytes{Name: "cover" + coverExt, Bytes: img})
+	if _, err := b.tg.Send(photo); err != nil {
+		b.log.Error("send cover", "user_id", userID, "err", err)
+	}
+	b.storeCover(ctx, userID, id, img)
+}
+
+func (b *Bot) generateCover(ctx context.Context, prompt string) ([]byte, error) {
+	release, err := b.acquireAI(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(ctx, b.cfg.AITimeout)
+	defer cancel()
+
+	start := time.Now()
+	var resp openai.ImageResponse
+	err = withRetry(ctx, func() (err error) {
+		resp, err = b.ai.CreateImage(ctx, openai.ImageRequest{
+			Prompt:         prompt,
+			Model:          openai.CreateImageModelDallE3,
+			N:              1,
+			Size:           openai.CreateImageSize1024x1024,
+			ResponseFormat: openai.CreateImageResponseFormatB64JSON,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	b.log.Info("image", "model", openai.CreateImageModelDallE3, "latency", time.Since(start))
+	if len(resp.Data) == 0 {
+		return nil, errors.New("bot: image response has no data")
+	}
+	return base64.StdEncoding.DecodeString(resp.Data[0].B64JSON)
+}
+
+// storeCover keeps a cover next to the episode's audio in Config.AudioDir
+// and records it so the feed can show it.
+func (b *Bot) storeCover(ctx context.Context, userID, id int64, img []byte) {
+	if b.cfg.AudioDir == "" || id == 0 {
+		return
+	}
+	name := fmt.Sprintf("%d%s", id, coverExt)
+	if err := os.WriteFile(filepath.Join(b.cfg.AudioDir, name), img, 0644); err != nil {
+		b.log.Error("store cover", "user_id", userID, "episode_id", id, "err", err)
+		return
+	}
+	if err := b.repo.SetEpisodeImage(ctx, id, name); err != nil {
+		b.log.Error("store cover", "user_id", userID, "episode_id", id, "err", err)
+	}
+}
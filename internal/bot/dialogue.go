@@ -0,0 +1,86 @@
+package bot
+
+import (
+	"regexp"
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+const dialoguePrompt = " Write it as a conversation between two hosts, Alex and Sam, taking turns." +
+	" Start every turn with the speaker's name in capitals and a colon, like \"ALEX:\" or \"SAM:\"."
+
+// dialogueHosts are the speaker tags dialoguePrompt asks for, in voice
+// order: the first reads with the user's voice, the second with
+// Config.DialogueVoice.
+var dialogueHosts = []string{"ALEX", "SAM"}
+
+var speakerTagRe = regexp.MustCompile(`(?i)^[\s*_]*(alex|sam)[*_]*\s*:[*_]*\s*`)
+
+// dialogueTurn is one host's uninterrupted speech.
+type dialogueTurn struct {
+	Host int // index into dialogueHosts
+	Text string
+}
+
+// parseDialogue splits a two-host script into turns. Untagged lines
+// continue the current turn and text before the first tag is dropped. It
+// returns nil unless both hosts speak, so callers fall back to one voice.
+func parseDialogue(script string) []dialogueTurn {
+	var turns []dialogueTurn
+	var spoke [2]bool
+	for _, line := range strings.Split(script, "\n") {
+		if m := speakerTagRe.FindStringSubmatch(line); m != nil {
+			host := 0
+			if strings.EqualFold(m[1], dialogueHosts[1]) {
+				host = 1
+			}
+			spoke[host] = true
+			line = line[len(m[0]):]
+			if len(turns) == 0 || turns[len(turns)-1].Host != host {
+				turns = append(turns, dialogueTurn{Host: host})
+			}
+		}
+		if len(turns) > 0 && strings.TrimSpace(line) != "" {
+			t := &turns[len(turns)-1]
+			t.Text = strings.TrimSpace(t.Text + "\n" + line)
+		}
+	}
+	if !spoke[0] || !spoke[1] {
+		return nil
+	}
+	return turns
+}
+
+// stripSpeakerTags removes speaker tags so a script that is not a usable
+// dialogue can be read by one voice.
+func stripSpeakerTags(script string) string {
+	lines := strings.Split(script, "\n")
+	for i, line := range lines {
+		lines[i] = speakerTagRe.ReplaceAllString(line, "")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// secondVoice picks the voice for the second host: Config.DialogueVoice,
+// or the first voice that differs from the user's.
+func (b *Bot) secondVoice(first openai.SpeechVoice) openai.SpeechVoice {
+	if v := openai.SpeechVoice(b.cfg.DialogueVoice); v != "" && v != first {
+		return v
+	}
+	for _, v := range showVoices {
+		if v != first {
+			return v
+		}
+	}
+	return first
+}
+
+func isVoice(name string) bool {
+	for _, v := range showVoices {
+		if string(v) == name {
+			return true
+		}
+	}
+	return false
+}
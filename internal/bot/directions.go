@@ -0,0 +1,105 @@
+package bot
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+const noDirectionsPrompt = " Write only the words the narrator says: no stage directions, sound cues or speaker labels."
+
+// noCuesPrompt replaces noDirectionsPrompt for dialogue and segmented
+// scripts, whose speaker tags or section headers must stay.
+const noCuesPrompt = " Apart from those, write only the words spoken: no stage directions or sound cues."
+
+var (
+	multiSpaceRe   = regexp.MustCompile(`[ \t]{2,}`)
+	spacePunctRe   = regexp.MustCompile(`[ \t]+([.,!?;:)])`)
+	blankLinesRe   = regexp.MustCompile(`\n\s*\n\s*\n+`)
+	bracketClosers = map[rune]rune{'[': ']', '(': ')'}
+)
+
+// cueWords mark a parenthetical aside as a production cue rather than
+// part of the narration, e.g. "(laughs)" or "(music fades out)".
+var cueWords = map[string]bool{
+	"music": true, "sound": true, "sounds": true, "sfx": true, "jingle": true,
+	"theme": true, "intro": true, "outro": true, "transition": true, "ambient": true,
+	"fade": true, "fades": true, "fading": true, "pause": true, "pauses": true,
+	"beat": true, "silence": true, "applause": true, "cheering": true,
+	"laugh": true, "laughs": true, "laughing": true, "laughter": true,
+	"chuckle": true, "chuckles": true, "sigh": true, "sighs": true,
+	"whisper": true, "whispers": true, "whispering": true,
+	"cough": true, "coughs": true, "gasps": true, "clears": true,
+}
+
+// maxCueWords bounds how long a parenthetical cue can be; longer asides
+// are narration even when they mention music or a pause.
+const maxCueWords = 6
+
+// stripStageDirections removes [bracketed] asides such as "[Intro music
+// fades]" and parenthetical cues such as "(laughs)" so TTS does not read
+// them aloud. Other parentheticals, like "(WHO)" or "(see 2019)", are
+// narration and stay. Nested and mixed brackets go with their outermost
+// aside; unbalanced openers are kept.
+func stripStageDirections(text string) string {
+	runes := []rune(text)
+	closes := matchBrackets(runes)
+	var sb strings.Builder
+	for i := 0; i < len(runes); i++ {
+		if end := closes[i]; end > 0 && (runes[i] == '[' || isCue(runes[i+1:end])) {
+			i = end
+			continue
+		}
+		sb.WriteRune(runes[i])
+	}
+
+	out := multiSpaceRe.ReplaceAllString(sb.String(), " ")
+	out = spacePunctRe.ReplaceAllString(out, "$1")
+	out = blankLinesRe.ReplaceAllString(out, "\n\n")
+
+	lines := strings.Split(out, "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimSpace(l)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// matchBrackets returns, for each opening bracket in runes, the index of
+// the bracket closing it, and 0 elsewhere. A closer that does not match
+// the innermost open bracket is ignored, so "(a [b) c]" pairs only the
+// square brackets. It scans runes once, however many openers go unclosed.
+func matchBrackets(runes []rune) []int {
+	closes := make([]int, len(runes))
+	var open []int
+	for i, r := range runes {
+		if _, ok := bracketClosers[r]; ok {
+			open = append(open, i)
+			continue
+		}
+		if n := len(open); n > 0 && r == bracketClosers[runes[open[n-1]]] {
+			closes[open[n-1]] = i
+			open = open[:n-1]
+		}
+	}
+	return closes
+}
+
+// isCue reports whether the inside of a parenthetical is a production cue:
+// empty, or a few words including one from cueWords.
+func isCue(inner []rune) bool {
+	words := strings.FieldsFunc(strings.ToLower(string(inner)), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	if len(words) == 0 {
+		return strings.TrimSpace(string(inner)) == ""
+	}
+	if len(words) > maxCueWords {
+		return false
+	}
+	for _, w := range words {
+		if cueWords[w] {
+			return true
+		}
+	}
+	return false
+}
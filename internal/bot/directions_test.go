@@ -0,0 +1,52 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStripStageDirections(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"bracketed cue", "[Intro music fades] Welcome back.", "Welcome back."},
+		{"parenthetical cue", "That was close (laughs). Anyway.", "That was close. Anyway."},
+		{"cue mid-sentence", "Today (pause) we talk about bees.", "Today we talk about bees."},
+		{"uppercase cue", "(SFX) Hello.", "Hello."},
+		{"acronym kept", "The World Health Organization (WHO) agrees.", "The World Health Organization (WHO) agrees."},
+		{"citation kept", "Bees are in decline (see 2019).", "Bees are in decline (see 2019)."},
+		{"long aside kept", "Jazz (the music of a very different era) returns.", "Jazz (the music of a very different era) returns."},
+		{"bracket always stripped", "Results [citation needed] vary.", "Results vary."},
+		{"nested brackets", "[Music [soft] plays] Hi.", "Hi."},
+		{"paren in bracket", "[Theme (reprise) swells] Hi.", "Hi."},
+		{"bracket in kept paren", "Read it (page [12]) today.", "Read it (page) today."},
+		{"nested cue", "(laughs (quietly)) Right.", "Right."},
+		{"mismatched closers", "(a [b) c] end", "(a end"},
+		{"unmatched bracket kept", "Prices [rise. Then fall.", "Prices [rise. Then fall."},
+		{"unmatched paren kept", "Wait (sighs. Go on.", "Wait (sighs. Go on."},
+		{"unmatched before a cue", "One ( two [music] three.", "One ( two three."},
+		{"empty parens", "Hello () world.", "Hello world."},
+		{"cue on its own line", "First.\n(music swells)\nSecond.", "First.\n\nSecond."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stripStageDirections(tt.in); got != tt.want {
+				t.Errorf("stripStageDirections(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStripStageDirectionsUnmatchedIsLinear(t *testing.T) {
+	in := strings.Repeat("([", 100000) + " words"
+	start := time.Now()
+	if got := stripStageDirections(in); !strings.HasSuffix(got, "words") {
+		t.Fatalf("lost text, got suffix %q", got[len(got)-10:])
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Errorf("took %v on unmatched openers", d)
+	}
+}
@@ -0,0 +1,126 @@
+package bot
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// maxUploadSize is Telegram's limit on files bots may send.
+const maxUploadSize = 50 << 20
+
+// archivePrefix names temp zip files, apart from the temp audio files.
+const archivePrefix = "podcaster-archive-"
+
+// handleDownload sends the user a zip of every stored episode: each
+// script as text and, when AudioDir keeps it, its audio.
+func (b *Bot) handleDownload(userID int64) {
+	if b.cfg.DatabasePath == "" {
+		b.tg.Send(tgbotapi.NewMessage(userID, b.localized(userID, msgNoStorage)))
+		return
+	}
+	eps, err := b.repo.UserEpisodes(context.Background(), userID)
+	if err != nil {
+		b.log.Error("list episodes", "user_id", userID, "err", err)
+		b.sendDownloadError(userID)
+		return
+	}
+	if len(eps) == 0 {
+		b.tg.Send(tgbotapi.NewMessage(userID, b.localized(userID, msgNoPodcasts)))
+		return
+	}
+
+	b.tg.Request(tgbotapi.NewChatAction(userID, tgbotapi.ChatUploadDocument))
+	f, err := os.CreateTemp(b.cfg.OutputDir, archivePrefix+"*.zip")
+	if err != nil {
+		b.log.Error("create archive", "user_id", userID, "err", err)
+		b.sendDownloadError(userID)
+		return
+	}
+	b.trackTempFile(f.Name())
+	defer b.removeTempFile(f.Name())
+	defer f.Close()
+
+	if err := b.writeArchive(f, eps); err != nil {
+		b.log.Error("write archive", "user_id", userID, "err", err)
+		b.sendDownloadError(userID)
+		return
+	}
+	size, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		b.sendDownloadError(userID)
+		return
+	}
+	if size > maxUploadSize {
+		b.tg.Send(tgbotapi.NewMessage(userID, b.localized(userID, msgArchiveTooBig)))
+		return
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		b.sendDownloadError(userID)
+		return
+	}
+
+	doc := tgbotapi.NewDocument(userID, tgbotapi.FileReader{Name: "podcasts.zip", Reader: f})
+	doc.Caption = fmt.Sprintf("%d podcasts", len(eps))
+	if _, err := b.tg.Send(doc); err != nil {
+		b.log.Error("send archive", "user_id", userID, "err", err)
+		b.sendDownloadError(userID)
+	}
+}
+
+// writeArchive streams eps into a zip in w, naming each episode's files
+// after its ID and topic. Audio missing from AudioDir is skipped.
+func (b *Bot) writeArchive(w io.Writer, eps []StoredEpisode) error {
+	zw := zip.NewWriter(w)
+	for _, ep := range eps {
+		name := sanitizeFilename(fmt.Sprintf("%d - %s", ep.ID, ep.Topic))
+		script, err := zw.Create(name + ".txt")
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(script, ep.Script); err != nil {
+			return err
+		}
+
+		if ep.AudioPath == "" || b.cfg.AudioDir == "" {
+			continue
+		}
+		if err := addArchiveFile(zw, name+filepath.Ext(ep.AudioPath), filepath.Join(b.cfg.AudioDir, ep.AudioPath)); err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+			b.log.Warn("archive audio missing", "episode_id", ep.ID, "path", ep.AudioPath)
+		}
+	}
+	return zw.Close()
+}
+
+// addArchiveFile copies the file at path into zw as name. Audio is already
+// compressed, so it is stored rather than deflated.
+func addArchiveFile(zw *zip.Writer, name, path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, src)
+	return err
+}
+
+// sendDownloadError reports a failed /download without touching the
+// user's place in the podcast flow.
+func (b *Bot) sendDownloadError(userID int64) {
+	b.tg.Send(tgbotapi.NewMessage(userID, b.localized(userID, msgArchiveFailed)))
+}
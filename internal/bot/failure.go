@@ -0,0 +1,55 @@
+package bot
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const retryPrefix = "retry:"
+
+// Steps of the flow a user can retry after a failure.
+const (
+	stepTopics = "topics"
+	stepScript = "script"
+	stepAudio  = "audio"
+)
+
+// sendError reports a failure in step. A step failure offers a Retry button
+// that re-runs just that step, keeping the user's category, topic and
+// script; with no step the error is unrecoverable and the user starts over
+// from the categories.
+func (b *Bot) sendError(userID int64, step string) {
+	b.sendFailure(userID, b.localized(userID, msgError), step)
+}
+
+func (b *Bot) sendFailure(userID int64, text, step string) {
+	msg := tgbotapi.NewMessage(userID, text)
+	if step == "" {
+		b.tg.Send(msg)
+		b.sendCategories(userID)
+		return
+	}
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("🔁 Retry", retryPrefix+step),
+	))
+	b.tg.Send(msg)
+}
+
+// handleRetry re-runs a failed step from the user's current state, or
+// starts over if what it needs is gone.
+func (b *Bot) handleRetry(userID int64, step string) {
+	st := b.getState(userID)
+	b.mu.Lock()
+	category, topic, script := st.Category, st.Topic, st.ScriptText
+	b.mu.Unlock()
+
+	switch {
+	case step == stepTopics && category != "":
+		b.generateTopics(userID)
+	case step == stepScript && topic != "":
+		b.handleTopicSelection(userID, topic)
+	case step == stepAudio && script != "":
+		b.recordAudio(userID, script)
+	default:
+		b.sendCategories(userID)
+	}
+}
@@ -0,0 +1,132 @@
+package bot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	favPrefix    = "fav:"
+	maxFavorites = 10
+)
+
+// Favorite is a topic the user bookmarked for later.
+type Favorite struct {
+	Category string
+	Topic    string
+}
+
+// favoriteKey identifies fav in callback data. It is derived from the
+// favorite itself, so a button still means the same topic after others are
+// removed, and it fits Telegram's 64-byte limit however long the topic.
+func favoriteKey(fav Favorite) string {
+	sum := sha256.Sum256([]byte(fav.Category + "\x00" + fav.Topic))
+	return hex.EncodeToString(sum[:6])
+}
+
+// handleFavoriteCallback dispatches "save", "go:<key>" and "del:<key>"
+// actions.
+func (b *Bot) handleFavoriteCallback(userID int64, action string) {
+	switch {
+	case action == "save":
+		b.saveFavorite(userID)
+	case strings.HasPrefix(action, "go:"):
+		if fav, ok := b.favoriteAt(userID, strings.TrimPrefix(action, "go:")); ok {
+			st := b.getState(userID)
+			b.mu.Lock()
+			st.Category = fav.Category
+			b.mu.Unlock()
+			b.handleTopicSelection(userID, fav.Topic)
+		}
+	case strings.HasPrefix(action, "del:"):
+		b.removeFavorite(userID, strings.TrimPrefix(action, "del:"))
+		b.sendFavorites(userID)
+	}
+}
+
+func (b *Bot) saveFavorite(userID int64) {
+	st := b.getState(userID)
+	b.mu.Lock()
+	fav := Favorite{Category: st.Category, Topic: st.Topic}
+	key := msgFavSaved
+	switch {
+	case fav.Topic == "":
+		key = msgFavNothing
+	case containsFavorite(st.Prefs.Favorites, fav):
+		key = msgFavExists
+	case len(st.Prefs.Favorites) >= maxFavorites:
+		key = msgFavFull
+	default:
+		st.Prefs.Favorites = append(st.Prefs.Favorites, fav)
+	}
+	b.mu.Unlock()
+
+	b.tg.Send(tgbotapi.NewMessage(userID, b.localized(userID, key)))
+}
+
+// favoriteIndex returns the position of the favorite with key, or -1.
+func favoriteIndex(favs []Favorite, key string) int {
+	for i, fav := range favs {
+		if favoriteKey(fav) == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func (b *Bot) favoriteAt(userID int64, key string) (Favorite, bool) {
+	st := b.getState(userID)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	i := favoriteIndex(st.Prefs.Favorites, key)
+	if i < 0 {
+		return Favorite{}, false
+	}
+	return st.Prefs.Favorites[i], true
+}
+
+func (b *Bot) removeFavorite(userID int64, key string) {
+	st := b.getState(userID)
+	b.mu.Lock()
+	if i := favoriteIndex(st.Prefs.Favorites, key); i >= 0 {
+		st.Prefs.Favorites = append(st.Prefs.Favorites[:i:i], st.Prefs.Favorites[i+1:]...)
+	}
+	b.mu.Unlock()
+}
+
+func (b *Bot) sendFavorites(userID int64) {
+	st := b.getState(userID)
+	b.mu.Lock()
+	favs := append([]Favorite(nil), st.Prefs.Favorites...)
+	b.mu.Unlock()
+
+	if len(favs) == 0 {
+		b.tg.Send(tgbotapi.NewMessage(userID, b.localized(userID, msgNoFavorites)))
+		return
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, fav := range favs {
+		key := favoriteKey(fav)
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fav.Topic, favPrefix+"go:"+key),
+			tgbotapi.NewInlineKeyboardButtonData("✖", favPrefix+"del:"+key),
+		))
+	}
+
+	msg := tgbotapi.NewMessage(userID, b.localized(userID, msgFavorites))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	b.tg.Send(msg)
+}
+
+func containsFavorite(favs []Favorite, fav Favorite) bool {
+	for _, f := range favs {
+		if f == fav {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package bot
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFavoriteButtonsSurviveRemovals(t *testing.T) {
+	b, _, ai := newTestBot(t, Config{})
+	favs := []Favorite{
+		{Category: "Health", Topic: "Sleep"},
+		{Category: "Travel", Topic: longTopic},
+		{Category: "ML", Topic: "Transformers"},
+	}
+	st := b.getState(testUser)
+	b.mu.Lock()
+	st.Prefs.Favorites = append([]Favorite(nil), favs...)
+	b.mu.Unlock()
+
+	for _, fav := range favs {
+		if data := favPrefix + "del:" + favoriteKey(fav); len(data) > maxCallbackData {
+			t.Errorf("%q has %d bytes of data", fav.Topic, len(data))
+		}
+	}
+
+	// Both buttons were on screen before the first favorite was removed.
+	goLast := favPrefix + "go:" + favoriteKey(favs[2])
+	b.handleUpdate(tap(favPrefix + "del:" + favoriteKey(favs[0])))
+	if got := b.stateOf(testUser).Prefs.Favorites; !reflect.DeepEqual(got, favs[1:]) {
+		t.Fatalf("favorites = %+v, want %+v", got, favs[1:])
+	}
+
+	b.handleUpdate(tap(goLast))
+	if got := b.stateOf(testUser); got.Topic != favs[2].Topic || got.Category != favs[2].Category {
+		t.Errorf("the button chose %q in %q, want %q", got.Topic, got.Category, favs[2].Topic)
+	}
+	if !ai.prompted(favs[2].Topic) {
+		t.Errorf("no prompt mentions %q", favs[2].Topic)
+	}
+
+	// A removed favorite's button does nothing.
+	b.handleUpdate(tap(favPrefix + "del:" + favoriteKey(favs[0])))
+	if got := b.stateOf(testUser).Prefs.Favorites; len(got) != 2 {
+		t.Errorf("favorites = %+v after a stale delete", got)
+	}
+}
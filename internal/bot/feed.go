@@ -0,0 +1,296 @@
+package bot
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	feedLimit          = 100
+	feedDescriptionLen = 500
+)
+
+// storeAudio copies a delivered episode's audio into Config.AudioDir and
+// records it in the repository so the feed can serve it. It returns the
+// stored file's name, or "" if it was not stored.
+func (b *Bot) storeAudio(ctx context.Context, userID int64, f *os.File, ext string) string {
+	st := b.getState(userID)
+	b.mu.Lock()
+	id := st.EpisodeID
+	b.mu.Unlock()
+	if b.cfg.AudioDir == "" || id == 0 {
+		return ""
+	}
+
+	name := fmt.Sprintf("%d%s", id, ext)
+	if err := copyAudio(filepath.Join(b.cfg.AudioDir, name), f); err != nil {
+		b.log.Error("store audio", "user_id", userID, "episode_id", id, "err", err)
+		return ""
+	}
+	if err := b.repo.SetEpisodeAudio(ctx, id, name); err != nil {
+		b.log.Error("store audio", "user_id", userID, "episode_id", id, "err", err)
+		return ""
+	}
+	return name
+}
+
+func copyAudio(path string, src *os.File) error {
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	dst, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		os.Remove(path)
+		return err
+	}
+	return dst.Close()
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Itunes  string     `xml:"xmlns:itunes,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string         `xml:"title"`
+	Link          string         `xml:"link"`
+	Description   string         `xml:"description"`
+	Language      string         `xml:"language"`
+	LastBuildDate string         `xml:"lastBuildDate"`
+	Author        string         `xml:"itunes:author"`
+	Explicit      string         `xml:"itunes:explicit"`
+	Category      itunesCategory `xml:"itunes:category"`
+	Image         *itunesImage   `xml:"itunes:image,omitempty"`
+	Items         []rssItem      `xml:"item"`
+}
+
+type itunesCategory struct {
+	Text string `xml:"text,attr"`
+}
+
+type rssItem struct {
+	Title       string       `xml:"title"`
+	Description string       `xml:"description"`
+	Enclosure   rssEnclosure `xml:"enclosure"`
+	GUID        rssGUID      `xml:"guid"`
+	PubDate     string       `xml:"pubDate"`
+	Explicit    string       `xml:"itunes:explicit"`
+	Image       *itunesImage `xml:"itunes:image,omitempty"`
+}
+
+type itunesImage struct {
+	Href string `xml:"href,attr"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// feedToken is the unguessable part of a user's feed URLs, an HMAC of
+// their ID keyed with Config.FeedSecret.
+func (b *Bot) feedToken(userID int64) string {
+	mac := hmac.New(sha256.New, []byte(b.cfg.FeedSecret))
+	fmt.Fprintf(mac, "feed:%d", userID)
+	return hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+// feedBase is the URL under which a user's feed and files are served.
+func (b *Bot) feedBase(userID int64) string {
+	return fmt.Sprintf("%s/u/%d/%s", strings.TrimRight(b.cfg.FeedURL, "/"), userID, b.feedToken(userID))
+}
+
+// audioURL is the public URL of a user's stored file, or "" when no feed
+// server serves it.
+func (b *Bot) audioURL(userID int64, name string) string {
+	if b.cfg.FeedAddr == "" || name == "" {
+		return ""
+	}
+	return b.feedBase(userID) + "/audio/" + name
+}
+
+// sendFeedLink gives the user the private URL of their feed.
+func (b *Bot) sendFeedLink(userID int64) {
+	if b.cfg.FeedAddr == "" {
+		b.tg.Send(tgbotapi.NewMessage(userID, b.localized(userID, msgNoFeed)))
+		return
+	}
+	b.tg.Send(tgbotapi.NewMessage(userID, b.localizedf(userID, msgFeedLink, b.feedBase(userID)+"/feed.xml")))
+}
+
+// buildFeed renders a user's stored episodes as an RSS 2.0 podcast feed.
+// Episodes whose audio file is missing are left out.
+func (b *Bot) buildFeed(userID int64, eps []StoredEpisode) rssFeed {
+	root := strings.TrimRight(b.cfg.FeedURL, "/")
+	base := b.feedBase(userID)
+	feed := rssFeed{
+		Version: "2.0",
+		Itunes:  "http://www.itunes.com/dtds/podcast-1.0.dtd",
+		Channel: rssChannel{
+			Title:         "Podcaster",
+			Link:          base + "/feed.xml",
+			Description:   "AI-generated podcasts from the Podcaster Telegram bot.",
+			Language:      b.cfg.SpeechLang,
+			LastBuildDate: time.Now().Format(time.RFC1123Z),
+			Author:        "Podcaster",
+			Explicit:      "false",
+			Category:      itunesCategory{Text: "Technology"},
+		},
+	}
+	if b.cfg.FeedImageURL != "" {
+		feed.Channel.Image = &itunesImage{Href: b.cfg.FeedImageURL}
+	}
+
+	for _, ep := range eps {
+		mime, ok := mimeTypeByExt(ep.AudioPath)
+		fi, err := os.Stat(filepath.Join(b.cfg.AudioDir, ep.AudioPath))
+		if !ok || err != nil {
+			continue
+		}
+		item := rssItem{
+			Title:       ep.Topic,
+			Description: scriptExcerpt(ep.Script),
+			Enclosure: rssEnclosure{
+				URL:    base + "/audio/" + ep.AudioPath,
+				Length: fi.Size(),
+				Type:   mime,
+			},
+			GUID:     rssGUID{Value: fmt.Sprintf("%s/episodes/%d", root, ep.ID)},
+			PubDate:  ep.CreatedAt.Format(time.RFC1123Z),
+			Explicit: "false",
+		}
+		if ep.ImagePath != "" {
+			item.Image = &itunesImage{Href: base + "/audio/" + ep.ImagePath}
+		}
+		feed.Channel.Items = append(feed.Channel.Items, item)
+	}
+	return feed
+}
+
+// scriptExcerpt returns the first lines of a script, up to
+// feedDescriptionLen runes.
+func scriptExcerpt(script string) string {
+	var lines []string
+	for _, line := range strings.Split(script, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+		if len(lines) == 3 {
+			break
+		}
+	}
+	out := strings.Join(lines, "\n")
+	if runes := []rune(out); len(runes) > feedDescriptionLen {
+		out = strings.TrimSpace(string(runes[:feedDescriptionLen])) + "…"
+	}
+	return out
+}
+
+// feedEpisodes returns up to feedLimit of a user's episodes with stored
+// audio, newest first.
+func (b *Bot) feedEpisodes(ctx context.Context, userID int64) ([]StoredEpisode, error) {
+	all, err := b.repo.UserEpisodes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	var eps []StoredEpisode
+	for i := len(all) - 1; i >= 0 && len(eps) < feedLimit; i-- {
+		if all[i].AudioPath != "" {
+			eps = append(eps, all[i])
+		}
+	}
+	return eps, nil
+}
+
+// handleUserFeed serves /u/<user ID>/<token>/feed.xml and the user's files
+// under /u/<user ID>/<token>/audio/. A wrong token is indistinguishable
+// from a missing page.
+func (b *Bot) handleUserFeed(w http.ResponseWriter, r *http.Request) {
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/u/"), "/", 3)
+	if len(parts) != 3 {
+		http.NotFound(w, r)
+		return
+	}
+	userID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || !hmac.Equal([]byte(parts[1]), []byte(b.feedToken(userID))) {
+		http.NotFound(w, r)
+		return
+	}
+
+	eps, err := b.feedEpisodes(r.Context(), userID)
+	if err != nil {
+		b.log.Error("list episodes", "user_id", userID, "err", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	if parts[2] == "feed.xml" {
+		w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+		io.WriteString(w, xml.Header)
+		enc := xml.NewEncoder(w)
+		enc.Indent("", "  ")
+		if err := enc.Encode(b.buildFeed(userID, eps)); err != nil {
+			b.log.Error("encode feed", "err", err)
+		}
+		return
+	}
+
+	name, ok := strings.CutPrefix(parts[2], "audio/")
+	if !ok || name == "" {
+		http.NotFound(w, r)
+		return
+	}
+	// Only the user's own episodes' files are served, so the stored names
+	// never need checking for path tricks.
+	for _, ep := range eps {
+		if name == ep.AudioPath || name == ep.ImagePath {
+			http.ServeFile(w, r, filepath.Join(b.cfg.AudioDir, name))
+			return
+		}
+	}
+	http.NotFound(w, r)
+}
+
+// serveFeed serves each user's private RSS feed, with their stored audio
+// and covers, under /u/.
+func (b *Bot) serveFeed(ctx context.Context, addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/u/", b.handleUserFeed)
+	srv := &http.Server{Addr: addr, Handler: mux}
+
+	go func() {
+		<-ctx.Done()
+		srv.Shutdown(context.Background())
+	}()
+
+	b.log.Info("serving feed", "addr", addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		b.log.Error("feed server", "err", err)
+	}
+}
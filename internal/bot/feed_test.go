@@ -0,0 +1,25 @@
+package bot
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestFeedChannelArtwork(t *testing.T) {
+	const art = "https://podcasts.example.com/art.png"
+	for _, image := range []string{"", art} {
+		b, _, _ := newTestBot(t, Config{FeedURL: "https://podcasts.example.com", FeedSecret: "secret", FeedImageURL: image})
+		out, err := xml.Marshal(b.buildFeed(testUser, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		tag := `<itunes:image href="` + art + `"></itunes:image>`
+		if got := strings.Contains(string(out), tag); got != (image != "") {
+			t.Errorf("with FeedImageURL %q the channel artwork is %v:\n%s", image, got, out)
+		}
+		if image == "" && strings.Contains(string(out), "itunes:image") {
+			t.Errorf("feed without artwork has an itunes:image:\n%s", out)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ratePrefix marks rating buttons, whose data is "rate:<episode ID>:up" or
+// "rate:<episode ID>:down" so a tap rates the episode it was sent with.
+const ratePrefix = "rate:"
+
+const maxFeedbackLen = 2000
+
+// ratingRow holds the 👍/👎 buttons attached to delivered audio.
+func ratingRow(episodeID int64) []tgbotapi.InlineKeyboardButton {
+	data := ratePrefix + strconv.FormatInt(episodeID, 10) + ":"
+	return tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("👍", data+"up"),
+		tgbotapi.NewInlineKeyboardButtonData("👎", data+"down"),
+	)
+}
+
+// handleRating records a rating button tap and returns the text to answer
+// the callback with.
+func (b *Bot) handleRating(userID int64, data string) string {
+	idText, vote, _ := strings.Cut(data, ":")
+	id, err := strconv.ParseInt(idText, 10, 64)
+	var rating int
+	switch vote {
+	case "up":
+		rating = 1
+	case "down":
+		rating = -1
+	}
+	if err != nil || rating == 0 {
+		return ""
+	}
+
+	b.log.Info("rating", "user_id", userID, "episode_id", id, "rating", rating)
+	if id != 0 {
+		if err := b.repo.SetEpisodeRating(context.Background(), id, rating); err != nil {
+			b.log.Error("save rating", "user_id", userID, "episode_id", id, "err", err)
+		}
+	}
+	return b.localized(userID, msgFeedbackThanks)
+}
+
+// handleFeedback forwards a /feedback comment to the admins.
+func (b *Bot) handleFeedback(userID int64, from *tgbotapi.User, text string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		b.tg.Send(tgbotapi.NewMessage(userID, b.localized(userID, msgFeedbackUsage)))
+		return
+	}
+	if r := []rune(text); len(r) > maxFeedbackLen {
+		text = string(r[:maxFeedbackLen])
+	}
+
+	name := strconv.FormatInt(userID, 10)
+	if from != nil {
+		name = fmt.Sprintf("%s (%d)", from.String(), from.ID)
+	}
+	b.log.Info("feedback", "user_id", userID, "text", truncateLog(text))
+	for _, admin := range b.cfg.AdminIDs {
+		b.tg.Send(tgbotapi.NewMessage(admin, b.localizedf(admin, msgFeedbackFrom, name, text)))
+	}
+	b.tg.Send(tgbotapi.NewMessage(userID, b.localized(userID, msgFeedbackThanks)))
+}
@@ -0,0 +1,54 @@
+package bot
+
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
+const maxFilenameLen = 100
+
+// audioFilename renders the configured template for a user's episode.
+func (b *Bot) audioFilename(userID int64, ext string) string {
+	st := b.getState(userID)
+	b.mu.Lock()
+	category, topic := st.Category, st.Topic
+	b.mu.Unlock()
+
+	r := strings.NewReplacer(
+		"{category}", category,
+		"{topic}", topic,
+		"{date}", time.Now().Format("2006-01-02"),
+	)
+	return sanitizeFilename(r.Replace(b.cfg.FilenameTemplate)) + ext
+}
+
+// sanitizeFilename makes name safe to use as a file name on common
+// filesystems. It never returns an empty string.
+func sanitizeFilename(name string) string {
+	var sb strings.Builder
+	space := false
+	for _, r := range name {
+		switch {
+		case strings.ContainsRune(`/\:*?"<>|`, r), unicode.IsControl(r):
+			r = '_'
+		case unicode.IsSpace(r):
+			if !space {
+				sb.WriteRune(' ')
+			}
+			space = true
+			continue
+		}
+		space = false
+		sb.WriteRune(r)
+	}
+
+	out := strings.Trim(sb.String(), " .")
+	if runes := []rune(out); len(runes) > maxFilenameLen {
+		out = strings.TrimRight(string(runes[:maxFilenameLen]), " .")
+	}
+	if out == "" {
+		return "podcast"
+	}
+	return out
+}
@@ -0,0 +1,196 @@
+package bot
+
+import (
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// flowInput is one thing a user did during the step-by-step flow, where
+// /new leads to the categories, a category to its topics and a topic to a
+// script.
+type flowInput struct {
+	// Command is a command without its slash, e.g. "new".
+	Command string
+	// Text is what the user typed, when it is not a command.
+	Text string
+	// Data is the callback data of a tapped button.
+	Data string
+}
+
+// Keyboards an outgoing message can carry.
+const (
+	keyboardNone = iota
+	keyboardCategories
+)
+
+// outgoing is a message a transition sends: a catalog key and, optionally,
+// one of the keyboards above.
+type outgoing struct {
+	Key      string
+	Keyboard int
+}
+
+// Work a transition leaves to the Bot once the next state is stored.
+const (
+	thenNothing = iota
+	// thenTopics asks the model for topics in the next state's Category.
+	thenTopics
+	// thenScript writes a script about the next state's Topic.
+	thenScript
+)
+
+// transition is the outcome of a flowInput.
+type transition struct {
+	Next UserState
+	// Fresh replaces the user's state with Next instead of updating it in
+	// place, so jobs still holding the old state can no longer touch it.
+	Fresh bool
+	// CancelJob stops the user's in-flight generation first.
+	CancelJob bool
+	Send      []outgoing
+	Then      int
+}
+
+const maxTopicLen = 200
+
+// advance decides what in does to a user in state st. It has no side
+// effects, so the flow can be checked without Telegram or OpenAI. ok is
+// false for inputs outside the flow, such as /settings. Inputs the flow
+// rejects, like a tap on a button from an earlier step, leave the state
+// as it is.
+func advance(st UserState, in flowInput, isCategory func(string) bool) (t transition, ok bool) {
+	t.Next = st
+	switch {
+	case in.Command == "new":
+		fresh := UserState{Prefs: st.Prefs, Length: st.Length, TopicsGen: st.TopicsGen, ScriptGen: st.ScriptGen}
+		t = showCategories(fresh)
+		t.Fresh = true
+	case in.Command == "category":
+		st.Topic = ""
+		st.ScriptText = ""
+		st.Segments = nil
+		st.Title = ""
+		st.EpisodeID = 0
+		t = showCategories(st)
+	case in.Command == "cancel":
+		t.Next = UserState{WaitingFor: StateInitial, Prefs: st.Prefs, Length: st.Length, TopicsGen: st.TopicsGen, ScriptGen: st.ScriptGen}
+		t.Fresh = true
+		t.CancelJob = true
+		t.Send = []outgoing{{Key: msgCancelled}}
+	case in.Command != "":
+		return t, false
+	case in.Data != "":
+		return tapButton(st, in.Data, isCategory)
+	case st.WaitingFor == StateInitial:
+		t = showCategories(st)
+	case st.WaitingFor == StateCategory:
+		// Categories are only chosen with the buttons.
+	case st.WaitingFor == StateTopic:
+		topic := strings.TrimSpace(in.Text)
+		if topic == "" || strings.HasPrefix(topic, "/") {
+			t.Send = []outgoing{{Key: msgTapTopic}}
+			break
+		}
+		if r := []rune(topic); len(r) > maxTopicLen {
+			topic = string(r[:maxTopicLen])
+		}
+		t.Next.Topic = topic
+		t.Then = thenScript
+	default:
+		return t, false
+	}
+	return t, true
+}
+
+// tapButton is advance for the category and topic buttons.
+func tapButton(st UserState, data string, isCategory func(string) bool) (t transition, ok bool) {
+	t.Next = st
+	if !strings.HasPrefix(data, categoryPrefix) && !strings.HasPrefix(data, topicPrefix) {
+		return t, false
+	}
+	if !acceptsButton(st.WaitingFor, data) {
+		return t, true
+	}
+
+	if category, ok := strings.CutPrefix(data, categoryPrefix); ok {
+		if isCategory(category) {
+			t.Next.Category = category
+			t.Next.Angle = ""
+			t.Next.SuggestedTopics = nil
+			t.Next.WaitingFor = StateTopic
+			t.Then = thenTopics
+		}
+		return t, true
+	}
+
+	if gen, i, ok := parseTopicData(data); ok && gen == st.TopicsGen && i >= 0 && i < len(st.Topics) {
+		t.Next.Topic = st.Topics[i]
+		t.Then = thenScript
+	}
+	return t, true
+}
+
+// showCategories moves st to category selection and sends the categories.
+func showCategories(st UserState) transition {
+	st.WaitingFor = StateCategory
+	st.TopicsMessageID = 0
+	st.Page = 0
+	return transition{
+		Next: st,
+		Send: []outgoing{{Key: msgChooseCategory, Keyboard: keyboardCategories}},
+	}
+}
+
+// step runs in through the flow for userID and reports whether the flow
+// handled it.
+func (b *Bot) step(userID int64, in flowInput) bool {
+	return b.apply(userID, func(st UserState) (transition, bool) {
+		return advance(st, in, b.isCategory)
+	})
+}
+
+// apply stores the transition decide returns for the user's state, then
+// sends its messages and starts its follow-up work.
+func (b *Bot) apply(userID int64, decide func(UserState) (transition, bool)) bool {
+	st := b.getState(userID)
+	b.mu.Lock()
+	t, ok := decide(*st)
+	if ok {
+		if t.CancelJob {
+			b.cancelJobLocked(userID)
+		}
+		if t.Fresh {
+			next := t.Next
+			b.states[userID] = &next
+		} else {
+			*st = t.Next
+		}
+	}
+	b.mu.Unlock()
+	if !ok {
+		return false
+	}
+
+	for _, out := range t.Send {
+		msg := tgbotapi.NewMessage(userID, b.localized(userID, out.Key))
+		if out.Keyboard == keyboardCategories {
+			msg.ReplyMarkup = b.categoryKeyboard(0)
+		}
+		b.tg.Send(msg)
+	}
+	switch t.Then {
+	case thenTopics:
+		b.generateTopics(userID)
+	case thenScript:
+		b.handleTopicSelection(userID, t.Next.Topic)
+	}
+	return true
+}
+
+// sendCategories starts category selection over, e.g. after an error.
+func (b *Bot) sendCategories(userID int64) {
+	b.apply(userID, func(st UserState) (transition, bool) {
+		return showCategories(st), true
+	})
+}
@@ -0,0 +1,230 @@
+package bot
+
+import "testing"
+
+func isTestCategory(name string) bool { return name == "Science" }
+
+func TestAdvance(t *testing.T) {
+	topicState := UserState{
+		WaitingFor: StateTopic,
+		Category:   "Science",
+		Topics:     []string{"Black holes", "A very long topic about the many strange moons of the outer planets"},
+		TopicsGen:  3,
+	}
+
+	tests := []struct {
+		name     string
+		st       UserState
+		in       flowInput
+		wantOK   bool
+		waiting  string
+		topic    string
+		category string
+		send     []string
+		then     int
+	}{
+		{
+			name:    "new shows categories",
+			st:      UserState{WaitingFor: StateReviewScript, Topic: "Old"},
+			in:      flowInput{Command: "new"},
+			wantOK:  true,
+			waiting: StateCategory,
+			send:    []string{msgChooseCategory},
+		},
+		{
+			name:    "text before /new shows categories",
+			st:      UserState{WaitingFor: StateInitial},
+			in:      flowInput{Text: "hi"},
+			wantOK:  true,
+			waiting: StateCategory,
+			send:    []string{msgChooseCategory},
+		},
+		{
+			name:     "category tap asks for topics",
+			st:       UserState{WaitingFor: StateCategory},
+			in:       flowInput{Data: categoryPrefix + "Science"},
+			wantOK:   true,
+			waiting:  StateTopic,
+			category: "Science",
+			then:     thenTopics,
+		},
+		{
+			name:     "topic tap resolves the full topic",
+			st:       topicState,
+			in:       flowInput{Data: topicData(3, 1)},
+			wantOK:   true,
+			waiting:  StateTopic,
+			category: "Science",
+			topic:    topicState.Topics[1],
+			then:     thenScript,
+		},
+		{
+			name:     "typed topic",
+			st:       topicState,
+			in:       flowInput{Text: "  Volcanoes  "},
+			wantOK:   true,
+			waiting:  StateTopic,
+			category: "Science",
+			topic:    "Volcanoes",
+			then:     thenScript,
+		},
+		{
+			name:    "cancel resets",
+			st:      topicState,
+			in:      flowInput{Command: "cancel"},
+			wantOK:  true,
+			waiting: StateInitial,
+			send:    []string{msgCancelled},
+		},
+		{
+			name:     "category tap while choosing a topic",
+			st:       topicState,
+			in:       flowInput{Data: categoryPrefix + "Science"},
+			wantOK:   true,
+			waiting:  StateTopic,
+			category: "Science",
+		},
+		{
+			name:    "topic tap while choosing a category",
+			st:      UserState{WaitingFor: StateCategory, Topics: []string{"Old"}},
+			in:      flowInput{Data: topicData(0, 0)},
+			wantOK:  true,
+			waiting: StateCategory,
+		},
+		{
+			name:     "tap on an older topic list",
+			st:       topicState,
+			in:       flowInput{Data: topicData(2, 0)},
+			wantOK:   true,
+			waiting:  StateTopic,
+			category: "Science",
+		},
+		{
+			name:     "topic index out of range",
+			st:       topicState,
+			in:       flowInput{Data: topicData(3, 9)},
+			wantOK:   true,
+			waiting:  StateTopic,
+			category: "Science",
+		},
+		{
+			name:    "unknown category",
+			st:      UserState{WaitingFor: StateCategory},
+			in:      flowInput{Data: categoryPrefix + "Cooking"},
+			wantOK:  true,
+			waiting: StateCategory,
+		},
+		{
+			name:    "text while choosing a category",
+			st:      UserState{WaitingFor: StateCategory},
+			in:      flowInput{Text: "Science"},
+			wantOK:  true,
+			waiting: StateCategory,
+		},
+		{
+			name:     "command text while choosing a topic",
+			st:       topicState,
+			in:       flowInput{Text: "/unknown"},
+			wantOK:   true,
+			waiting:  StateTopic,
+			category: "Science",
+			send:     []string{msgTapTopic},
+		},
+		{
+			name: "other commands are not the flow's",
+			st:   topicState,
+			in:   flowInput{Command: "settings"},
+		},
+		{
+			name: "other buttons are not the flow's",
+			st:   topicState,
+			in:   flowInput{Data: voicePrefix + "nova"},
+		},
+		{
+			name: "script edits are not the flow's",
+			st:   UserState{WaitingFor: StateEditScript},
+			in:   flowInput{Text: "A new script"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := advance(tt.st, tt.in, isTestCategory)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if got.Next.WaitingFor != tt.waiting {
+				t.Errorf("waiting for %q, want %q", got.Next.WaitingFor, tt.waiting)
+			}
+			if got.Next.Topic != tt.topic {
+				t.Errorf("topic = %q, want %q", got.Next.Topic, tt.topic)
+			}
+			if got.Next.Category != tt.category {
+				t.Errorf("category = %q, want %q", got.Next.Category, tt.category)
+			}
+			if got.Then != tt.then {
+				t.Errorf("then = %d, want %d", got.Then, tt.then)
+			}
+			var keys []string
+			for _, out := range got.Send {
+				keys = append(keys, out.Key)
+			}
+			if len(keys) != len(tt.send) {
+				t.Fatalf("sent %q, want %q", keys, tt.send)
+			}
+			for i := range keys {
+				if keys[i] != tt.send[i] {
+					t.Errorf("sent %q, want %q", keys, tt.send)
+				}
+			}
+		})
+	}
+}
+
+func TestAdvanceKeepsPrefsAcrossNew(t *testing.T) {
+	st := UserState{
+		WaitingFor: StateReviewScript,
+		Topic:      "Old",
+		ScriptText: "Old script",
+		Length:     LengthShort,
+		Prefs:      Prefs{DeliveryFormat: FormatVoice},
+	}
+	got, _ := advance(st, flowInput{Command: "new"}, isTestCategory)
+	if !got.Fresh {
+		t.Error("/new updates the state in place")
+	}
+	if got.Next.ScriptText != "" || got.Next.Topic != "" {
+		t.Errorf("/new kept the script %q and topic %q", got.Next.ScriptText, got.Next.Topic)
+	}
+	if got.Next.Length != LengthShort || got.Next.Prefs.DeliveryFormat != FormatVoice {
+		t.Errorf("/new dropped preferences: %+v", got.Next)
+	}
+	if st.Topic != "Old" {
+		t.Error("advance modified its input")
+	}
+}
+
+func TestAdvanceCategoryDropsScript(t *testing.T) {
+	st := UserState{WaitingFor: StateReviewScript, Category: "Science", Topic: "Old", ScriptText: "s", EpisodeID: 7}
+	got, ok := advance(st, flowInput{Command: "category"}, isTestCategory)
+	if !ok || got.Fresh {
+		t.Fatalf("ok = %v, fresh = %v", ok, got.Fresh)
+	}
+	if got.Next.WaitingFor != StateCategory || got.Next.Topic != "" || got.Next.ScriptText != "" || got.Next.EpisodeID != 0 {
+		t.Errorf("/category left %+v", got.Next)
+	}
+}
+
+func TestAdvanceTruncatesLongTypedTopics(t *testing.T) {
+	long := make([]rune, maxTopicLen+50)
+	for i := range long {
+		long[i] = 'é'
+	}
+	got, _ := advance(UserState{WaitingFor: StateTopic}, flowInput{Text: string(long)}, isTestCategory)
+	if n := len([]rune(got.Next.Topic)); n != maxTopicLen {
+		t.Errorf("topic has %d runes, want %d", n, maxTopicLen)
+	}
+}
@@ -0,0 +1,108 @@
+package bot
+
+import (
+	"path/filepath"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+const formatPrefix = "format:"
+
+// Delivery formats a user can choose with /format.
+const (
+	FormatAudio = "audio"
+	FormatVoice = "voice"
+)
+
+// DefaultAudioFormat is used when Config.AudioFormat is empty.
+const DefaultAudioFormat = "mp3"
+
+// audioEncoding is a TTS output format and how its files are named and
+// served.
+type audioEncoding struct {
+	Format openai.SpeechResponseFormat
+	Ext    string
+	MIME   string
+	// Codec is the ffmpeg encoder for post-processed audio.
+	Codec string
+	// Joinable formats are frame streams that still play when files are
+	// simply concatenated.
+	Joinable bool
+}
+
+// audioEncodings are the values Config.AudioFormat accepts.
+var audioEncodings = map[string]audioEncoding{
+	"mp3":  {openai.SpeechResponseFormatMp3, ".mp3", "audio/mpeg", "libmp3lame", true},
+	"opus": {openai.SpeechResponseFormatOpus, ".ogg", "audio/ogg", "libopus", false},
+	"aac":  {openai.SpeechResponseFormatAac, ".aac", "audio/aac", "aac", true},
+	"flac": {openai.SpeechResponseFormatFlac, ".flac", "audio/flac", "flac", false},
+}
+
+// audioEncoding picks the TTS output for a user and whether to send it as
+// a voice message. Telegram voice messages must be OGG/Opus, so voice
+// delivery always uses opus, and opus audio is always sent as voice.
+func (b *Bot) audioEncoding(userID int64) (audioEncoding, bool) {
+	if b.deliveryFormat(userID) == FormatVoice || b.cfg.AudioFormat == "opus" {
+		return audioEncodings["opus"], true
+	}
+	return audioEncodings[b.cfg.AudioFormat], false
+}
+
+// mimeTypeByExt returns the MIME type of a stored audio file, or false if
+// the extension is not one the bot writes.
+func mimeTypeByExt(name string) (string, bool) {
+	ext := filepath.Ext(name)
+	for _, enc := range audioEncodings {
+		if enc.Ext == ext {
+			return enc.MIME, true
+		}
+	}
+	return "", false
+}
+
+func (b *Bot) deliveryFormat(userID int64) string {
+	st := b.getState(userID)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if st.Prefs.DeliveryFormat == FormatVoice {
+		return FormatVoice
+	}
+	return FormatAudio
+}
+
+func (b *Bot) sendFormats(userID int64) {
+	current := b.deliveryFormat(userID)
+	label := func(format, text string) string {
+		if format == current {
+			return "✅ " + text
+		}
+		return text
+	}
+
+	msg := tgbotapi.NewMessage(userID, b.localized(userID, msgChooseFormat))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(label(FormatAudio, "🎵 Audio file"), formatPrefix+FormatAudio),
+			tgbotapi.NewInlineKeyboardButtonData(label(FormatVoice, "🎤 Voice message"), formatPrefix+FormatVoice),
+		),
+	)
+	b.tg.Send(msg)
+}
+
+func (b *Bot) handleFormatSelection(userID int64, format string) {
+	if format != FormatAudio && format != FormatVoice {
+		return
+	}
+
+	st := b.getState(userID)
+	b.mu.Lock()
+	st.Prefs.DeliveryFormat = format
+	b.mu.Unlock()
+
+	key := msgFormatAudio
+	if format == FormatVoice {
+		key = msgFormatVoice
+	}
+	b.tg.Send(tgbotapi.NewMessage(userID, b.localized(userID, key)))
+}
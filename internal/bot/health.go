@@ -0,0 +1,53 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// healthStatus is the JSON body of the health endpoints.
+type healthStatus struct {
+	Status string `json:"status"`
+	BuildInfo
+}
+
+func (b *Bot) writeHealth(w http.ResponseWriter, code int, status string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(healthStatus{Status: status, BuildInfo: b.build})
+}
+
+// handleHealthz answers liveness probes: the process is up.
+func (b *Bot) handleHealthz(w http.ResponseWriter, _ *http.Request) {
+	b.writeHealth(w, http.StatusOK, "ok")
+}
+
+// handleReadyz answers readiness probes: Telegram accepted the bot's token
+// and command list.
+func (b *Bot) handleReadyz(w http.ResponseWriter, _ *http.Request) {
+	if !b.ready.Load() {
+		b.writeHealth(w, http.StatusServiceUnavailable, "starting")
+		return
+	}
+	b.writeHealth(w, http.StatusOK, "ready")
+}
+
+// serveHealth exposes /healthz and /readyz on addr until ctx is done.
+func (b *Bot) serveHealth(ctx context.Context, addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", b.handleHealthz)
+	mux.HandleFunc("/readyz", b.handleReadyz)
+	srv := &http.Server{Addr: addr, Handler: mux}
+
+	go func() {
+		<-ctx.Done()
+		srv.Shutdown(context.Background())
+	}()
+
+	b.log.Info("serving health checks", "addr", addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		b.log.Error("health server", "err", err)
+	}
+}
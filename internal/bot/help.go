@@ -0,0 +1,95 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// commands are registered with Telegram and listed by /help, so both stay
+// in sync.
+var commands = []tgbotapi.BotCommand{
+	{Command: "new", Description: "Start new podcast creation"},
+	{Command: "category", Description: "Pick another category, keeping your settings"},
+	{Command: "text", Description: "Get generated podcast text"},
+	{Command: "history", Description: "List your recent podcasts"},
+	{Command: "feed", Description: "Get your private podcast feed URL"},
+	{Command: "download", Description: "Download all your saved podcasts as a zip"},
+	{Command: "replay", Description: "Re-send a podcast from /history"},
+	{Command: "stats", Description: "Show how much you've used the bot"},
+	{Command: "regenerate", Description: "Write a fresh script for the same topic"},
+	{Command: "status", Description: "Show whether a podcast is being generated"},
+	{Command: "cancel", Description: "Cancel the current podcast creation"},
+	{Command: "topics", Description: "Suggest different topics for the current category"},
+	{Command: "angle", Description: "Regenerate topics from a different angle"},
+	{Command: "favorites", Description: "List saved topics"},
+	{Command: "settings", Description: "Show and change all your preferences"},
+	{Command: "myshow", Description: "View or edit your show's voice, style, language and speed"},
+	{Command: "length", Description: "Choose podcast length"},
+	{Command: "format", Description: "Receive podcasts as audio files or voice messages"},
+	{Command: "quality", Description: "Choose standard or HD narration"},
+	{Command: "voice", Description: "Choose the narrator voice"},
+	{Command: "speed", Description: "Set the narration speed"},
+	{Command: "language", Description: "Choose the language for topics and scripts"},
+	{Command: "style", Description: "Switch narration style"},
+	{Command: "expressive", Description: "Opt in or out of expressive narration"},
+	{Command: "feedback", Description: "Send a comment to the bot's operators"},
+	{Command: "help", Description: "Show how the bot works"},
+	{Command: "version", Description: "Show the bot's version"},
+}
+
+// commandDescriptions translates the Description of commands by language
+// code; commands keep their English ones for other languages.
+var commandDescriptions = map[string]map[string]string{
+	"es": {
+		"new":        "Crear un podcast nuevo",
+		"category":   "Elegir otra categoría sin perder tus ajustes",
+		"text":       "Recibir el texto del podcast",
+		"history":    "Ver tus podcasts recientes",
+		"feed":       "Recibir la URL de tu feed privado",
+		"download":   "Descargar todos tus podcasts guardados en un zip",
+		"replay":     "Volver a enviar un podcast de /history",
+		"stats":      "Ver cuánto has usado el bot",
+		"regenerate": "Escribir un guion nuevo sobre el mismo tema",
+		"status":     "Ver si se está generando un podcast",
+		"cancel":     "Cancelar la creación del podcast",
+		"topics":     "Sugerir otros temas de la categoría",
+		"angle":      "Generar temas desde otro enfoque",
+		"favorites":  "Ver tus temas guardados",
+		"settings":   "Ver y cambiar todas tus preferencias",
+		"myshow":     "Ver o editar la voz, el estilo, el idioma y la velocidad de tu programa",
+		"length":     "Elegir la duración del podcast",
+		"format":     "Recibir podcasts como archivos de audio o mensajes de voz",
+		"quality":    "Elegir narración estándar o HD",
+		"voice":      "Elegir la voz del narrador",
+		"speed":      "Cambiar la velocidad de narración",
+		"language":   "Elegir el idioma de los temas y los guiones",
+		"style":      "Cambiar el estilo de narración",
+		"expressive": "Activar o desactivar la narración expresiva",
+		"feedback":   "Enviar un comentario a los operadores del bot",
+		"help":       "Ver cómo funciona el bot",
+		"version":    "Ver la versión del bot",
+	},
+}
+
+// helpText describes the flow and every registered command in the user's
+// language.
+func (b *Bot) helpText(userID int64) string {
+	lang := b.messageLang(userID)
+	var sb strings.Builder
+	sb.WriteString(b.localized(userID, msgHelpIntro))
+	for _, c := range commands {
+		desc := c.Description
+		if d, ok := commandDescriptions[lang][c.Command]; ok {
+			desc = d
+		}
+		fmt.Fprintf(&sb, "\n/%s - %s", c.Command, desc)
+	}
+	return sb.String()
+}
+
+// sendHelp leaves the user's state alone, so it is safe mid-flow.
+func (b *Bot) sendHelp(userID int64) {
+	b.tg.Send(tgbotapi.NewMessage(userID, b.helpText(userID)))
+}
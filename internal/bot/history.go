@@ -0,0 +1,298 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const pruneInterval = time.Hour
+
+// Episode is a generated podcast kept in a user's history.
+type Episode struct {
+	// ID identifies the episode within its user's History, so buttons can
+	// refer to it however the history shifts or the bot restarts.
+	ID        int64     `json:"id,omitempty"`
+	UserID    int64     `json:"user_id"`
+	Category  string    `json:"category"`
+	Topic     string    `json:"topic"`
+	Script    string    `json:"script"`
+	CreatedAt time.Time `json:"created_at"`
+	// AudioFileID is the Telegram file_id of the delivered audio.
+	AudioFileID string `json:"audio_file_id,omitempty"`
+	// Voice is set when the audio was delivered as a voice message.
+	Voice bool `json:"voice,omitempty"`
+	// AudioURL is where the stored audio can be downloaded, when the feed
+	// server serves it.
+	AudioURL string `json:"audio_url,omitempty"`
+}
+
+// DefaultHistorySize is how many episodes History keeps per user when no
+// other size is given.
+const DefaultHistorySize = 10
+
+// History keeps the most recent generated episodes per user, oldest first.
+type History struct {
+	size int
+
+	mu       sync.Mutex
+	lastID   map[int64]int64
+	episodes map[int64][]Episode
+}
+
+// NewHistory creates an empty History keeping up to size episodes per
+// user, or DefaultHistorySize if size is not positive.
+func NewHistory(size int) *History {
+	if size <= 0 {
+		size = DefaultHistorySize
+	}
+	return &History{size: size, lastID: make(map[int64]int64), episodes: make(map[int64][]Episode)}
+}
+
+// Restore seeds a user's history and ID counter from saved state, unless
+// the user already has episodes. Later IDs continue after lastID.
+func (h *History) Restore(userID int64, eps []Episode, lastID int64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastID[userID] = max(h.lastID[userID], lastID)
+	if len(h.episodes[userID]) == 0 && len(eps) > 0 {
+		h.episodes[userID] = append([]Episode(nil), eps...)
+	}
+}
+
+// Snapshot returns a copy of a user's episodes and the last ID given to
+// one, for saving with the user's state.
+func (h *History) Snapshot(userID int64) ([]Episode, int64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]Episode(nil), h.episodes[userID]...), h.lastID[userID]
+}
+
+// Add appends ep to its user's history with a new ID, dropping the oldest
+// episodes once the history is full. As in Prune, when export is set an
+// episode is only dropped once export succeeds for it; the rest stay until
+// a later Prune.
+func (h *History) Add(ep Episode, export func(Episode) error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastID[ep.UserID]++
+	ep.ID = h.lastID[ep.UserID]
+	eps := append(h.episodes[ep.UserID], ep)
+	if len(eps) <= h.size {
+		h.episodes[ep.UserID] = eps
+		return
+	}
+
+	var kept []Episode
+	for i, old := range eps {
+		if i >= len(eps)-h.size || (export != nil && export(old) != nil) {
+			kept = append(kept, old)
+		}
+	}
+	h.episodes[ep.UserID] = kept
+}
+
+// List returns a copy of a user's episodes, oldest first.
+func (h *History) List(userID int64) []Episode {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]Episode(nil), h.episodes[userID]...)
+}
+
+// Get returns a user's episode with the given ID, if it is still kept.
+func (h *History) Get(userID, id int64) (Episode, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, ep := range h.episodes[userID] {
+		if ep.ID == id {
+			return ep, true
+		}
+	}
+	return Episode{}, false
+}
+
+// Prune drops episodes older than maxAge and all but the newest maxCount
+// per user. Zero limits are ignored. When export is set, an episode is only
+// dropped once export succeeds for it. Prune returns the dropped episodes.
+func (h *History) Prune(now time.Time, maxAge time.Duration, maxCount int, export func(Episode) error) []Episode {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var pruned []Episode
+	for userID, eps := range h.episodes {
+		var kept []Episode
+		for i, ep := range eps {
+			tooOld := maxAge > 0 && now.Sub(ep.CreatedAt) > maxAge
+			tooMany := maxCount > 0 && i < len(eps)-maxCount
+			if !tooOld && !tooMany {
+				kept = append(kept, ep)
+				continue
+			}
+			if export != nil && export(ep) != nil {
+				kept = append(kept, ep)
+				continue
+			}
+			pruned = append(pruned, ep)
+		}
+		if len(kept) == 0 {
+			delete(h.episodes, userID)
+		} else {
+			h.episodes[userID] = kept
+		}
+	}
+	return pruned
+}
+
+// recordEpisode saves the episode just delivered to a user and notifies
+// the configured webhook.
+func (b *Bot) recordEpisode(userID int64, sent tgbotapi.Message, audioURL string) {
+	st := b.getState(userID)
+	b.mu.Lock()
+	ep := Episode{
+		UserID:    userID,
+		Category:  st.Category,
+		Topic:     st.Topic,
+		Script:    st.ScriptText,
+		CreatedAt: time.Now(),
+		AudioURL:  audioURL,
+	}
+	b.mu.Unlock()
+	switch {
+	case sent.Audio != nil:
+		ep.AudioFileID = sent.Audio.FileID
+	case sent.Voice != nil:
+		ep.AudioFileID = sent.Voice.FileID
+		ep.Voice = true
+	}
+
+	b.history.Add(ep, b.historyExport())
+	b.saveHistory(userID)
+	if b.cfg.WebhookURL != "" {
+		go b.sendWebhook(ep)
+	}
+}
+
+// saveHistory copies a user's history into their state and saves it, so
+// /history and replay buttons survive a restart.
+func (b *Bot) saveHistory(userID int64) {
+	eps, lastID := b.history.Snapshot(userID)
+	st := b.getState(userID)
+	b.mu.Lock()
+	st.Prefs.History = eps
+	st.Prefs.LastEpisodeID = lastID
+	b.mu.Unlock()
+	b.saveState(userID)
+}
+
+// runPruner enforces the configured history retention until ctx is done.
+func (b *Bot) runPruner(ctx context.Context) {
+	if b.cfg.HistoryMaxAge <= 0 && b.cfg.HistoryMaxEpisodes <= 0 {
+		return
+	}
+
+	export := b.historyExport()
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+	for {
+		now := time.Now()
+		if pruned := b.history.Prune(now, b.cfg.HistoryMaxAge, b.cfg.HistoryMaxEpisodes, export); len(pruned) > 0 {
+			b.log.Info("pruned history", "episodes", len(pruned))
+			saved := make(map[int64]bool)
+			for _, ep := range pruned {
+				if !saved[ep.UserID] {
+					saved[ep.UserID] = true
+					b.saveHistory(ep.UserID)
+				}
+			}
+		}
+		b.pruneStored(ctx, now)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// pruneStored applies the history limits to the repository, exporting
+// each stored episode and removing its files from AudioDir first.
+func (b *Bot) pruneStored(ctx context.Context, now time.Time) {
+	var cutoff time.Time
+	if b.cfg.HistoryMaxAge > 0 {
+		cutoff = now.Add(-b.cfg.HistoryMaxAge)
+	}
+	n, err := b.repo.PruneEpisodes(ctx, cutoff, b.cfg.HistoryMaxEpisodes, b.dropStored)
+	if err != nil {
+		b.log.Error("prune stored episodes", "err", err)
+	}
+	if n > 0 {
+		b.log.Info("pruned stored episodes", "episodes", n)
+	}
+}
+
+// dropStored exports ep when an export directory is configured and
+// deletes its audio and cover.
+func (b *Bot) dropStored(ep StoredEpisode) error {
+	if b.cfg.HistoryExportDir != "" {
+		err := b.exportEpisode(Episode{
+			UserID:    ep.UserID,
+			Category:  ep.Category,
+			Topic:     ep.Topic,
+			Script:    ep.Script,
+			CreatedAt: ep.CreatedAt,
+		})
+		if err != nil {
+			b.log.Error("export stored episode", "id", ep.ID, "err", err)
+			return err
+		}
+	}
+	if b.cfg.AudioDir == "" {
+		return nil
+	}
+	for _, name := range []string{ep.AudioPath, ep.ImagePath} {
+		if name == "" {
+			continue
+		}
+		if err := os.Remove(filepath.Join(b.cfg.AudioDir, name)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			b.log.Error("remove stored file", "id", ep.ID, "err", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// historyExport returns the func that saves episodes dropped from the
+// history, or nil when no export directory is configured.
+func (b *Bot) historyExport() func(Episode) error {
+	if b.cfg.HistoryExportDir == "" {
+		return nil
+	}
+	return func(ep Episode) error {
+		err := b.exportEpisode(ep)
+		if err != nil {
+			b.log.Error("export episode", "user_id", ep.UserID, "err", err)
+		}
+		return err
+	}
+}
+
+// exportEpisode writes ep as JSON into the configured export directory.
+func (b *Bot) exportEpisode(ep Episode) error {
+	if err := os.MkdirAll(b.cfg.HistoryExportDir, 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(ep, "", "  ")
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%d-%d.json", ep.UserID, ep.CreatedAt.UnixNano())
+	return os.WriteFile(filepath.Join(b.cfg.HistoryExportDir, name), data, 0644)
+}
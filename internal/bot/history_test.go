@@ -0,0 +1,226 @@
+package bot
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// topics returns the topics of eps, in order.
+func topics(eps []Episode) []string {
+	var ts []string
+	for _, ep := range eps {
+		ts = append(ts, ep.Topic)
+	}
+	return ts
+}
+
+func TestPruneBoundaries(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		maxAge   time.Duration
+		maxCount int
+		kept     []string
+	}{
+		{"no limits", 0, 0, []string{"old", "day", "hour", "new"}},
+		{"exactly max age is kept", 24 * time.Hour, 0, []string{"day", "hour", "new"}},
+		{"just under max age", 24*time.Hour - time.Nanosecond, 0, []string{"hour", "new"}},
+		{"max count keeps the newest", 0, 2, []string{"hour", "new"}},
+		{"max count above the size", 0, 10, []string{"old", "day", "hour", "new"}},
+		{"both limits", 2 * time.Hour, 3, []string{"hour", "new"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHistory(10)
+			for _, ep := range []Episode{
+				{Topic: "old", CreatedAt: now.Add(-48 * time.Hour)},
+				{Topic: "day", CreatedAt: now.Add(-24 * time.Hour)},
+				{Topic: "hour", CreatedAt: now.Add(-time.Hour)},
+				{Topic: "new", CreatedAt: now},
+			} {
+				ep.UserID = testUser
+				h.Add(ep, nil)
+			}
+			pruned := h.Prune(now, tt.maxAge, tt.maxCount, nil)
+			kept := topics(h.List(testUser))
+			if !reflect.DeepEqual(kept, tt.kept) {
+				t.Errorf("kept %q, want %q", kept, tt.kept)
+			}
+			if len(pruned)+len(kept) != 4 {
+				t.Errorf("pruned %q and kept %q of 4 episodes", topics(pruned), kept)
+			}
+		})
+	}
+}
+
+func TestPruneKeepsEpisodesThatFailToExport(t *testing.T) {
+	h := NewHistory(10)
+	h.Add(Episode{UserID: testUser, Topic: "a"}, nil)
+	h.Add(Episode{UserID: testUser, Topic: "b"}, nil)
+	h.Add(Episode{UserID: testUser, Topic: "c"}, nil)
+
+	var exported []string
+	export := func(ep Episode) error {
+		if ep.Topic == "a" {
+			return errors.New("disk full")
+		}
+		exported = append(exported, ep.Topic)
+		return nil
+	}
+	pruned := h.Prune(time.Now(), 0, 1, export)
+	if got := topics(pruned); !reflect.DeepEqual(got, []string{"b"}) {
+		t.Errorf("pruned %q, want only the exported episode", got)
+	}
+	if !reflect.DeepEqual(exported, []string{"b"}) {
+		t.Errorf("exported %q", exported)
+	}
+	if got := topics(h.List(testUser)); !reflect.DeepEqual(got, []string{"a", "c"}) {
+		t.Errorf("kept %q", got)
+	}
+}
+
+func TestHistoryEvictionExports(t *testing.T) {
+	dir := t.TempDir()
+	b, _, _ := newTestBot(t, Config{HistoryExportDir: dir})
+	b.history = NewHistory(1)
+	export := b.historyExport()
+	b.history.Add(Episode{UserID: testUser, Topic: "first", CreatedAt: time.Unix(1, 0)}, export)
+	b.history.Add(Episode{UserID: testUser, Topic: "second", CreatedAt: time.Unix(2, 0)}, export)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("exported %q, %v; want one file", files, err)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ep Episode
+	if err := json.Unmarshal(data, &ep); err != nil || ep.Topic != "first" || ep.UserID != testUser {
+		t.Errorf("exported %s, %v; want the first episode", data, err)
+	}
+	if got := topics(b.history.List(testUser)); !reflect.DeepEqual(got, []string{"second"}) {
+		t.Errorf("kept %q", got)
+	}
+}
+
+func TestHistoryIsCapped(t *testing.T) {
+	h := NewHistory(3)
+	for _, topic := range []string{"a", "b", "c", "d", "e"} {
+		h.Add(Episode{UserID: testUser, Topic: topic}, nil)
+	}
+	h.Add(Episode{UserID: testUser + 1, Topic: "other"}, nil)
+
+	eps := h.List(testUser)
+	if got := topics(eps); !reflect.DeepEqual(got, []string{"c", "d", "e"}) {
+		t.Errorf("kept %q, want the newest three", got)
+	}
+	if _, ok := h.Get(testUser, 1); ok {
+		t.Error("the oldest episode is still found by ID")
+	}
+	if ep, ok := h.Get(testUser, eps[0].ID); !ok || ep.Topic != "c" {
+		t.Errorf("Get(%d) = %q, %v", eps[0].ID, ep.Topic, ok)
+	}
+	if got := topics(h.List(testUser + 1)); !reflect.DeepEqual(got, []string{"other"}) {
+		t.Errorf("another user's history is %q", got)
+	}
+}
+
+// addEpisodes gives the test user one episode with audio per topic,
+// oldest first.
+func (b *Bot) addEpisodes(topics ...string) {
+	// Like recordEpisode, load the user's state, and with it their saved
+	// history, before adding to it.
+	b.getState(testUser)
+	for _, topic := range topics {
+		b.history.Add(Episode{
+			UserID:      testUser,
+			Topic:       topic,
+			Script:      "The script about " + topic + ".",
+			AudioFileID: "file-" + topic,
+			CreatedAt:   time.Now(),
+		}, nil)
+		b.saveHistory(testUser)
+	}
+}
+
+func TestHistoryCommand(t *testing.T) {
+	b, tg, _ := newTestBot(t, Config{})
+	b.handleUpdate(command("history"))
+	if got := tg.texts(); len(got) != 1 || got[0] != messages["en"][msgNoPodcasts] {
+		t.Errorf("empty history sent %q", got)
+	}
+
+	b.addEpisodes("Lighthouses", "Tides")
+	b.handleUpdate(command("history"))
+	got := tg.texts()[1]
+	first, second := strings.Index(got, "1. "), strings.Index(got, "2. ")
+	if first < 0 || second < 0 || !strings.Contains(got[first:second], "Tides") || !strings.Contains(got[second:], "Lighthouses") {
+		t.Errorf("/history sent %q, want the newest first", got)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	b, tg, _ := newTestBot(t, Config{})
+	b.addEpisodes("Lighthouses", "Tides")
+
+	b.handleUpdate(commandWith("replay", "2"))
+	msgs := tg.messages()
+	if len(msgs) != 2 || msgs[0].Text != "The script about Lighthouses." {
+		t.Fatalf("/replay 2 sent %q, want the older script and an audio button", tg.texts())
+	}
+	markup := msgs[1].ReplyMarkup.(tgbotapi.InlineKeyboardMarkup)
+	data := *markup.InlineKeyboard[0][0].CallbackData
+
+	// A newer episode shifts the numbers, but not what the button sends.
+	b.addEpisodes("Comets")
+	b.handleUpdate(tap(data))
+	tg.mu.Lock()
+	audio, ok := tg.sent[len(tg.sent)-1].(tgbotapi.AudioConfig)
+	tg.mu.Unlock()
+	if !ok || audio.File != tgbotapi.FileID("file-Lighthouses") {
+		t.Errorf("the button sent %+v, want the Lighthouses audio", audio)
+	}
+
+	for _, arg := range []string{"", "0", "4", "x"} {
+		before := len(tg.texts())
+		b.handleUpdate(commandWith("replay", arg))
+		if got := tg.texts()[before:]; len(got) != 1 || got[0] != messages["en"][msgReplayUsage] {
+			t.Errorf("/replay %q sent %q, want the usage", arg, got)
+		}
+	}
+}
+
+func TestReplaySurvivesRestarts(t *testing.T) {
+	dir := t.TempDir()
+	b, tg, _ := newTestBot(t, Config{StateDir: dir})
+	b.addEpisodes("Lighthouses", "Tides")
+	b.handleUpdate(commandWith("replay", "2"))
+	msgs := tg.messages()
+	markup := msgs[len(msgs)-1].ReplyMarkup.(tgbotapi.InlineKeyboardMarkup)
+	data := *markup.InlineKeyboard[0][0].CallbackData
+
+	restarted, tg, _ := newTestBot(t, Config{StateDir: dir})
+	restarted.addEpisodes("Comets")
+	if eps := restarted.recentEpisodes(testUser); !reflect.DeepEqual(topics(eps), []string{"Comets", "Tides", "Lighthouses"}) {
+		t.Fatalf("history after a restart = %q", topics(eps))
+	}
+
+	// The new episode gets a fresh ID, so the old button still sends
+	// the episode it was made for.
+	restarted.handleUpdate(tap(data))
+	tg.mu.Lock()
+	audio, ok := tg.sent[len(tg.sent)-1].(tgbotapi.AudioConfig)
+	tg.mu.Unlock()
+	if !ok || audio.File != tgbotapi.FileID("file-Lighthouses") {
+		t.Errorf("the button sent %+v after a restart, want the Lighthouses audio", audio)
+	}
+}
@@ -0,0 +1,88 @@
+package bot
+
+import (
+	"context"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// job is an in-flight generation that can be cancelled.
+type job struct {
+	id     uint64
+	cancel context.CancelFunc
+	// what is the message key describing the work for /status, e.g.
+	// msgJobScript.
+	what    string
+	started time.Time
+}
+
+// startJob begins a cancellable generation for userID, cancelling any
+// previous one. what is the message key describing it for /status. The returned done func must
+// be called when it finishes. Jobs also stop when Run's context is done.
+func (b *Bot) startJob(userID int64, what string) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(b.ctx)
+
+	b.mu.Lock()
+	if prev, ok := b.jobs[userID]; ok {
+		prev.cancel()
+	}
+	b.nextJob++
+	id := b.nextJob
+	b.jobs[userID] = job{id: id, cancel: cancel, what: what, started: time.Now()}
+	b.mu.Unlock()
+
+	return ctx, func() {
+		cancel()
+		b.mu.Lock()
+		if j, ok := b.jobs[userID]; ok && j.id == id {
+			delete(b.jobs, userID)
+		}
+		b.mu.Unlock()
+	}
+}
+
+// cancelJob stops the user's in-flight generation, if any.
+func (b *Bot) cancelJob(userID int64) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.cancelJobLocked(userID)
+}
+
+// cancelJobLocked is cancelJob for callers holding b.mu.
+func (b *Bot) cancelJobLocked(userID int64) bool {
+	j, ok := b.jobs[userID]
+	if ok {
+		j.cancel()
+		delete(b.jobs, userID)
+	}
+	return ok
+}
+
+// ifCurrent runs fn, which must not lock b.mu, if ctx's job has not been
+// cancelled. Jobs are only cancelled under b.mu, so once a newer job or
+// /cancel has replaced a job, its late results can no longer reach the
+// user's state.
+func (b *Bot) ifCurrent(ctx context.Context, fn func()) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if ctx.Err() != nil {
+		return false
+	}
+	fn()
+	return true
+}
+
+// sendStatus tells the user whether a generation is running.
+func (b *Bot) sendStatus(userID int64) {
+	b.mu.Lock()
+	j, ok := b.jobs[userID]
+	b.mu.Unlock()
+
+	text := b.localized(userID, msgIdle)
+	if ok {
+		secs := int(time.Since(j.started).Seconds())
+		text = b.localizedf(userID, msgBusy, b.localized(userID, j.what), secs)
+	}
+	b.tg.Send(tgbotapi.NewMessage(userID, text))
+}
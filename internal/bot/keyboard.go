@@ -0,0 +1,127 @@
+package bot
+
+import (
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// keyboardRows splits buttons into rows of at most perRow buttons.
+func keyboardRows(buttons []tgbotapi.InlineKeyboardButton, perRow int) [][]tgbotapi.InlineKeyboardButton {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for len(buttons) > 0 {
+		n := min(perRow, len(buttons))
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(buttons[:n]...))
+		buttons = buttons[n:]
+	}
+	return rows
+}
+
+const pagePrefix = "page:"
+
+// pageSize is how many options a paged keyboard shows at once, which stays
+// usable on a phone screen.
+const pageSize = 9
+
+// pageRows lays out one page of buttons in rows of perRow. Lists longer
+// than pageSize get a navigation row with Prev and Next buttons for the
+// neighbouring pages that exist; page is clamped to the available pages.
+func pageRows(buttons []tgbotapi.InlineKeyboardButton, perRow, page int) [][]tgbotapi.InlineKeyboardButton {
+	if len(buttons) <= pageSize {
+		return keyboardRows(buttons, perRow)
+	}
+	pages := (len(buttons) + pageSize - 1) / pageSize
+	page = max(0, min(page, pages-1))
+	start := page * pageSize
+	rows := keyboardRows(buttons[start:min(start+pageSize, len(buttons))], perRow)
+
+	var nav []tgbotapi.InlineKeyboardButton
+	if page > 0 {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData("◀ Prev", pagePrefix+strconv.Itoa(page-1)))
+	}
+	if page < pages-1 {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData("Next ▶", pagePrefix+strconv.Itoa(page+1)))
+	}
+	return append(rows, tgbotapi.NewInlineKeyboardRow(nav...))
+}
+
+// handlePage re-renders the category or topic keyboard in messageID at
+// page, depending on which the user is choosing. Other taps are ignored.
+func (b *Bot) handlePage(userID int64, messageID int, page string) {
+	n, err := strconv.Atoi(page)
+	if err != nil || n < 0 {
+		return
+	}
+
+	st := b.getState(userID)
+	b.mu.Lock()
+	waiting := st.WaitingFor
+	topics, gen := st.Topics, st.TopicsGen
+	if waiting == StateTopic && messageID != st.TopicsMessageID {
+		// Paging an older topic list would fill it with the latest topics.
+		waiting = ""
+	}
+	if waiting == StateCategory || waiting == StateTopic {
+		st.Page = n
+	}
+	b.mu.Unlock()
+
+	switch waiting {
+	case StateCategory:
+		b.editKeyboard(userID, messageID, b.categoryKeyboard(n))
+	case StateTopic:
+		b.editKeyboard(userID, messageID, topicsMarkup(topics, gen, n))
+	}
+}
+
+// editKeyboard replaces the inline keyboard of an earlier message. An
+// unchanged keyboard counts as success; other failures, e.g. because the
+// user deleted the message, return false so the caller can send a new one.
+func (b *Bot) editKeyboard(chatID int64, messageID int, markup tgbotapi.InlineKeyboardMarkup) bool {
+	_, err := b.tg.Request(tgbotapi.NewEditMessageReplyMarkup(chatID, messageID, markup))
+	if err == nil || strings.Contains(err.Error(), "message is not modified") {
+		return true
+	}
+	b.log.Debug("edit keyboard", "user_id", chatID, "err", err)
+	return false
+}
+
+// buttonSteps maps the callback data prefixes of step-by-step selection
+// buttons to the step they belong to.
+var buttonSteps = []struct{ prefix, state string }{
+	{categoryPrefix, StateCategory},
+	{anglePrefix, StateAngle},
+	{topicPrefix, StateTopic},
+	{backData, StateTopic},
+}
+
+// isStaleButton reports whether data belongs to a selection step the user
+// has already left, such as a category button from an earlier /new, to
+// a topic list that More topics has since replaced, or to a review of a
+// script since regenerated or edited.
+func (b *Bot) isStaleButton(userID int64, data string) bool {
+	st := b.getState(userID)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if gen, _, ok := parseTopicData(data); ok && gen != st.TopicsGen {
+		return true
+	}
+	if strings.HasPrefix(data, reviewPrefix) {
+		gen, _, ok := parseReviewData(data)
+		return !ok || gen != st.ScriptGen
+	}
+	return !acceptsButton(st.WaitingFor, data)
+}
+
+// acceptsButton reports whether a user waiting for state may tap a button
+// with callback data. Buttons outside the step-by-step selection, such as
+// settings, are accepted in any state.
+func acceptsButton(state, data string) bool {
+	for _, s := range buttonSteps {
+		if strings.HasPrefix(data, s.prefix) {
+			return state == s.state
+		}
+	}
+	return true
+}
@@ -0,0 +1,160 @@
+package bot
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func numberedButtons(n int) []tgbotapi.InlineKeyboardButton {
+	buttons := make([]tgbotapi.InlineKeyboardButton, n)
+	for i := range buttons {
+		buttons[i] = tgbotapi.NewInlineKeyboardButtonData(strconv.Itoa(i), strconv.Itoa(i))
+	}
+	return buttons
+}
+
+// navData returns the data of the page buttons in rows, and the labels
+// of the other buttons.
+func navData(rows [][]tgbotapi.InlineKeyboardButton) (nav, labels []string) {
+	for _, row := range rows {
+		for _, button := range row {
+			if data := *button.CallbackData; strings.HasPrefix(data, pagePrefix) {
+				nav = append(nav, data)
+			} else {
+				labels = append(labels, button.Text)
+			}
+		}
+	}
+	return nav, labels
+}
+
+func TestPageRows(t *testing.T) {
+	tests := []struct {
+		name    string
+		buttons int
+		page    int
+		nav     []string
+		first   string
+		shown   int
+	}{
+		{"fits on one page", pageSize, 0, nil, "0", pageSize},
+		{"first page", pageSize + 1, 0, []string{pagePrefix + "1"}, "0", pageSize},
+		{"last page", pageSize + 1, 1, []string{pagePrefix + "0"}, strconv.Itoa(pageSize), 1},
+		{"middle page", 3 * pageSize, 1, []string{pagePrefix + "0", pagePrefix + "2"}, strconv.Itoa(pageSize), pageSize},
+		{"past the end", 2 * pageSize, 5, []string{pagePrefix + "0"}, strconv.Itoa(pageSize), pageSize},
+		{"before the start", 2 * pageSize, -1, []string{pagePrefix + "1"}, "0", pageSize},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nav, labels := navData(pageRows(numberedButtons(tt.buttons), 3, tt.page))
+			if !reflect.DeepEqual(nav, tt.nav) {
+				t.Errorf("nav = %q, want %q", nav, tt.nav)
+			}
+			if len(labels) != tt.shown || labels[0] != tt.first {
+				t.Errorf("shows %q, want %d buttons from %q", labels, tt.shown, tt.first)
+			}
+		})
+	}
+}
+
+func TestPageRowsLayout(t *testing.T) {
+	rows := pageRows(numberedButtons(pageSize+1), 3, 0)
+	var widths []int
+	for _, row := range rows {
+		widths = append(widths, len(row))
+	}
+	// Three full rows, then Next on its own row.
+	if want := []int{3, 3, 3, 1}; !reflect.DeepEqual(widths, want) {
+		t.Errorf("row widths = %v, want %v", widths, want)
+	}
+}
+
+func TestCategoryKeyboard(t *testing.T) {
+	b, tg, _ := newTestBot(t, Config{})
+	b.handleUpdate(command("new"))
+
+	msgs := tg.messages()
+	if len(msgs) == 0 {
+		t.Fatal("/new sent nothing")
+	}
+	markup, ok := msgs[len(msgs)-1].ReplyMarkup.(tgbotapi.InlineKeyboardMarkup)
+	if !ok {
+		t.Fatalf("/new sent markup %T, want an inline keyboard", msgs[len(msgs)-1].ReplyMarkup)
+	}
+	if len(markup.InlineKeyboard) != 1 {
+		t.Errorf("keyboard has %d rows, want the categories on one", len(markup.InlineKeyboard))
+	}
+	var labels, data []string
+	for _, row := range markup.InlineKeyboard {
+		for _, btn := range row {
+			labels = append(labels, btn.Text)
+			if btn.CallbackData == nil {
+				t.Fatalf("button %q has no callback data", btn.Text)
+			}
+			data = append(data, *btn.CallbackData)
+		}
+	}
+	if want := []string{"Auto", "Health", "Travel", "ML", "Media"}; !reflect.DeepEqual(labels, want) {
+		t.Errorf("buttons = %q, want %q", labels, want)
+	}
+	if want := []string{"cat:Auto", "cat:Health", "cat:Travel", "cat:ML", "cat:Media"}; !reflect.DeepEqual(data, want) {
+		t.Errorf("callback data = %q, want %q", data, want)
+	}
+}
+
+func TestCategoryPaging(t *testing.T) {
+	var categories []string
+	for i := 0; i < pageSize+2; i++ {
+		categories = append(categories, "Category "+strconv.Itoa(i))
+	}
+	b, tg, _ := newTestBot(t, Config{Categories: categories})
+	b.handleUpdate(command("new"))
+
+	b.handleUpdate(tap(pagePrefix + "1"))
+	if got := b.stateOf(testUser).Page; got != 1 {
+		t.Errorf("page = %d, want 1", got)
+	}
+	tg.mu.Lock()
+	defer tg.mu.Unlock()
+	var edit tgbotapi.EditMessageReplyMarkupConfig
+	for _, c := range tg.requests {
+		if e, ok := c.(tgbotapi.EditMessageReplyMarkupConfig); ok {
+			edit = e
+		}
+	}
+	if edit.ReplyMarkup == nil {
+		t.Fatal("the keyboard was not edited")
+	}
+	nav, labels := navData(edit.ReplyMarkup.InlineKeyboard)
+	if want := []string{pagePrefix + "0"}; !reflect.DeepEqual(nav, want) || !reflect.DeepEqual(labels, categories[pageSize:]) {
+		t.Errorf("second page shows %q with nav %q", labels, nav)
+	}
+}
+
+func TestAcceptsButton(t *testing.T) {
+	tests := []struct {
+		state, data string
+		want        bool
+	}{
+		{StateCategory, categoryPrefix + "Health", true},
+		{StateTopic, categoryPrefix + "Health", false},
+		{StateTopic, topicData(0, 1), true},
+		{StateCategory, topicData(0, 1), false},
+		{StateReviewScript, topicData(0, 1), false},
+		{StateTopic, backData, true},
+		{StateInitial, backData, false},
+		{StateAngle, anglePrefix + "Advanced", true},
+		{StateTopic, anglePrefix + "Advanced", false},
+		{StateInitial, settingsPrefix + "length", true},
+		{StateTopic, voicePrefix + "nova", true},
+	}
+	for _, tt := range tests {
+		if got := acceptsButton(tt.state, tt.data); got != tt.want {
+			t.Errorf("acceptsButton(%q, %q) = %v, want %v", tt.state, tt.data, got, tt.want)
+		}
+	}
+}
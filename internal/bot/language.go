@@ -0,0 +1,59 @@
+package bot
+
+import (
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const languagePrefix = "lang:"
+
+// findLanguage matches a language by code or name, ignoring case.
+func findLanguage(s string) (language, bool) {
+	for _, l := range showLanguages {
+		if strings.EqualFold(l.Code, s) || strings.EqualFold(l.Name, s) {
+			return l, true
+		}
+	}
+	return language{}, false
+}
+
+// handleLanguageCommand sets the script language from args, or offers the
+// supported languages when args is empty or unknown. The choice is stored
+// in the user's show profile, so it survives /new.
+func (b *Bot) handleLanguageCommand(userID int64, args string) {
+	if l, ok := findLanguage(strings.TrimSpace(args)); ok {
+		b.handleLanguageSelection(userID, l.Code)
+		return
+	}
+
+	current := b.profile(userID).Language
+	if current == "" {
+		current = "en"
+	}
+
+	var buttons []tgbotapi.InlineKeyboardButton
+	for _, l := range showLanguages {
+		label := l.Name
+		if l.Code == current {
+			label = "✅ " + label
+		}
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(label, languagePrefix+l.Code))
+	}
+
+	msg := tgbotapi.NewMessage(userID, b.localized(userID, msgChooseLanguage))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(keyboardRows(buttons, 3)...)
+	b.tg.Send(msg)
+}
+
+func (b *Bot) handleLanguageSelection(userID int64, code string) {
+	st := b.getState(userID)
+	b.mu.Lock()
+	ok := applyShowOption(&st.Prefs.Show, "lang", code)
+	name := st.Prefs.Show.languageName()
+	b.mu.Unlock()
+	if !ok {
+		return
+	}
+	b.tg.Send(tgbotapi.NewMessage(userID, b.localizedf(userID, msgLanguageSet, name)))
+}
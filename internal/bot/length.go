@@ -0,0 +1,108 @@
+package bot
+
+import (
+	"regexp"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const lengthPrefix = "len:"
+
+// Script lengths a user can choose with /length.
+const (
+	LengthShort  = "short"
+	LengthMedium = "medium"
+	LengthLong   = "long"
+)
+
+// podcastLength is a target duration and the word count that fills it.
+type podcastLength struct {
+	Key     string
+	Label   string
+	Minutes int
+	Words   int
+}
+
+var podcastLengths = []podcastLength{
+	{LengthShort, "Short (~1 min)", 1, 130},
+	{LengthMedium, "Medium (~3 min)", 3, 400},
+	{LengthLong, "Long (~5 min)", 5, 650},
+}
+
+// findLength returns the length for key, defaulting to Medium.
+func findLength(key string) podcastLength {
+	for _, l := range podcastLengths {
+		if l.Key == key {
+			return l
+		}
+	}
+	return podcastLengths[1]
+}
+
+// scriptLength is the user's chosen length with its word count capped at
+// Config.MaxScriptWords.
+func (b *Bot) scriptLength(key string) podcastLength {
+	l := findLength(key)
+	if limit := b.cfg.MaxScriptWords; limit > 0 && limit < l.Words {
+		l.Words = limit
+	}
+	return l
+}
+
+var wordsRe = regexp.MustCompile(`\S+`)
+
+// trimToWords cuts script to at most limit words, so an overshooting model
+// cannot inflate TTS cost. It ends at the last sentence boundary in the
+// second half of the allowance, or with an ellipsis if there is none. The
+// second result reports whether anything was cut.
+func trimToWords(script string, limit int) (string, bool) {
+	words := wordsRe.FindAllStringIndex(script, limit+1)
+	if len(words) <= limit {
+		return script, false
+	}
+	head := script[:words[limit-1][1]]
+	half := words[limit/2][0]
+	if ends := sentenceEndRe.FindAllStringIndex(head+" ", -1); len(ends) > 0 {
+		if end := ends[len(ends)-1][1]; end > half {
+			return strings.TrimSpace(head[:min(end, len(head))]), true
+		}
+	}
+	return strings.TrimSpace(head) + "…", true
+}
+
+func (b *Bot) sendLengths(userID int64) {
+	st := b.getState(userID)
+	b.mu.Lock()
+	current := findLength(st.Length).Key
+	b.mu.Unlock()
+
+	var buttons []tgbotapi.InlineKeyboardButton
+	for _, l := range podcastLengths {
+		label := l.Label
+		if l.Key == current {
+			label = "✅ " + label
+		}
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(label, lengthPrefix+l.Key))
+	}
+
+	msg := tgbotapi.NewMessage(userID, b.localized(userID, msgChooseLength))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(buttons...),
+	)
+	b.tg.Send(msg)
+}
+
+func (b *Bot) handleLengthSelection(userID int64, key string) {
+	l := findLength(key)
+	if l.Key != key {
+		return
+	}
+
+	st := b.getState(userID)
+	b.mu.Lock()
+	st.Length = l.Key
+	b.mu.Unlock()
+
+	b.tg.Send(tgbotapi.NewMessage(userID, b.localizedf(userID, msgLengthSet, l.Label)))
+}
@@ -0,0 +1,57 @@
+package bot
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestTrimToWords(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		limit  int
+		want   string
+		cut    bool
+	}{
+		{"under the limit", "One two three.", 5, "One two three.", false},
+		{"at the limit", "One two three.", 3, "One two three.", false},
+		{"at a sentence boundary", "One two. Three four. Five six.", 5, "One two. Three four.", true},
+		{"boundary too early", "One. Two three four five six.", 5, "One. Two three four five…", true},
+		{"no boundary", "one two three four five six", 4, "one two three four…", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, cut := trimToWords(tt.script, tt.limit)
+			if got != tt.want || cut != tt.cut {
+				t.Errorf("trimToWords(%q, %d) = %q, %v; want %q, %v", tt.script, tt.limit, got, cut, tt.want, tt.cut)
+			}
+		})
+	}
+}
+
+func TestMaxScriptWords(t *testing.T) {
+	b, _, ai := newTestBot(t, Config{MaxScriptWords: 12})
+	long := strings.Repeat("A sentence of six words here. ", 10)
+	ai.chat = func(context.Context, openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+		return reply(long), nil
+	}
+	b.handleTopicSelection(testUser, "Lighthouses")
+
+	if !ai.prompted("12 words") {
+		t.Errorf("prompts %q do not ask for 12 words", ai.prompts)
+	}
+	if got := b.stateOf(testUser).ScriptText; len(strings.Fields(got)) != 12 {
+		t.Errorf("script = %q, want it trimmed to 12 words", got)
+	}
+}
+
+func TestDefaultScriptWords(t *testing.T) {
+	b, _, ai := newTestBot(t, Config{})
+	b.handleTopicSelection(testUser, "Lighthouses")
+	if !ai.prompted("under 400 words") {
+		t.Errorf("prompts %q do not ask for 400 words", ai.prompts)
+	}
+}
@@ -0,0 +1,344 @@
+package bot
+
+import "fmt"
+
+// Keys into messages for user-facing strings. Values with verbs are
+// fmt formats.
+const (
+	msgChooseCategory      = "choose_category"
+	msgChooseTopic         = "choose_topic"
+	msgGenerating          = "generating"
+	msgRecording           = "recording"
+	msgCaption             = "caption"
+	msgNoScript            = "no_script"
+	msgCancelled           = "cancelled"
+	msgError               = "error"
+	msgAITimeout           = "ai_timeout"
+	msgAINoChoices         = "ai_no_choices"
+	msgUnavailable         = "unavailable"
+	msgBudgetReached       = "budget_reached"
+	msgRateLimited         = "rate_limited"
+	msgUnauthorized        = "unauthorized"
+	msgStaleButton         = "stale_button"
+	msgQueued              = "queued"
+	msgSomethingWrong      = "something_wrong"
+	msgAdminOnly           = "admin_only"
+	msgBroadcastUsage      = "broadcast_usage"
+	msgBroadcastSent       = "broadcast_sent"
+	msgNeedCategory        = "need_category"
+	msgChooseAngle         = "choose_angle"
+	msgTapTopic            = "tap_topic"
+	msgPart                = "part"
+	msgReview              = "review"
+	msgNotReviewing        = "not_reviewing"
+	msgSendEdit            = "send_edit"
+	msgEditAsText          = "edit_as_text"
+	msgNothingToRegenerate = "nothing_to_regenerate"
+	msgRegenerateWait      = "regenerate_wait"
+	msgJobTopics           = "job_topics"
+	msgJobScript           = "job_script"
+	msgJobAudio            = "job_audio"
+	msgIdle                = "idle"
+	msgBusy                = "busy"
+	msgNoPodcasts          = "no_podcasts"
+	msgHistory             = "history"
+	msgHistoryHint         = "history_hint"
+	msgReplayUsage         = "replay_usage"
+	msgReplayAudio         = "replay_audio"
+	msgStats               = "stats"
+	msgStatsCategory       = "stats_category"
+	msgStatsTokens         = "stats_tokens"
+	msgFavSaved            = "fav_saved"
+	msgFavNothing          = "fav_nothing"
+	msgFavExists           = "fav_exists"
+	msgFavFull             = "fav_full"
+	msgNoFavorites         = "no_favorites"
+	msgFavorites           = "favorites"
+	msgNoStorage           = "no_storage"
+	msgArchiveTooBig       = "archive_too_big"
+	msgArchiveFailed       = "archive_failed"
+	msgNoFeed              = "no_feed"
+	msgFeedLink            = "feed_link"
+	msgFeedbackThanks      = "feedback_thanks"
+	msgFeedbackUsage       = "feedback_usage"
+	msgFeedbackFrom        = "feedback_from"
+	msgConsent             = "consent"
+	msgExpressiveOn        = "expressive_on"
+	msgExpressiveOff       = "expressive_off"
+	msgExpressiveNoOptIn   = "expressive_no_opt_in"
+	msgChooseQuality       = "choose_quality"
+	msgQualityPinned       = "quality_pinned"
+	msgQualityStandard     = "quality_standard"
+	msgQualityHD           = "quality_hd"
+	msgQualityOverridden   = "quality_overridden"
+	msgChooseFormat        = "choose_format"
+	msgFormatAudio         = "format_audio"
+	msgFormatVoice         = "format_voice"
+	msgChooseLanguage      = "choose_language"
+	msgLanguageSet         = "language_set"
+	msgChooseLength        = "choose_length"
+	msgLengthSet           = "length_set"
+	msgChooseVoice         = "choose_voice"
+	msgVoiceSet            = "voice_set"
+	msgChooseSpeed         = "choose_speed"
+	msgBadSpeed            = "bad_speed"
+	msgSpeedSet            = "speed_set"
+	msgStyles              = "styles"
+	msgUnknownStyle        = "unknown_style"
+	msgStyleCleared        = "style_cleared"
+	msgStyleSet            = "style_set"
+	msgChooseOption        = "choose_option"
+	msgShowHeader          = "show_header"
+	msgSettingsHeader      = "settings_header"
+	msgShowSummary         = "show_summary"
+	msgNoStyle             = "no_style"
+	msgSettingsSummary     = "settings_summary"
+	msgAudioFile           = "audio_file"
+	msgVoiceMessage        = "voice_message"
+	msgStandard            = "standard"
+	msgExpressiveSetting   = "expressive_setting"
+	msgOn                  = "on"
+	msgOff                 = "off"
+	msgSettingsHint        = "settings_hint"
+	msgHelpIntro           = "help_intro"
+)
+
+// messages holds user-facing strings by language code. English is the
+// fallback for languages and keys that are missing.
+var messages = map[string]map[string]string{
+	"en": {
+		msgChooseCategory:      "Choose podcast category:",
+		msgChooseTopic:         "Choose a specific topic, or type your own:",
+		msgGenerating:          "🎙 Generating your podcast...",
+		msgRecording:           "🎙 Recording your podcast...",
+		msgCaption:             "Here's your podcast, enjoy!",
+		msgNoScript:            "No script available. Please create a podcast first!",
+		msgCancelled:           "Cancelled. Send /new to start again.",
+		msgError:               "Error generating content. Please try again.",
+		msgAITimeout:           "The AI took too long, please try again.",
+		msgAINoChoices:         "The AI returned no answer, possibly due to content filtering. Please try another topic.",
+		msgUnavailable:         "Service temporarily unavailable, please try later.",
+		msgBudgetReached:       "Daily limit reached, try again tomorrow.",
+		msgRateLimited:         "You're going too fast, please wait a moment",
+		msgUnauthorized:        "You are not authorized to use this bot.",
+		msgStaleButton:         "This button is no longer active — send /new to start over.",
+		msgQueued:              "⏳ Finishing your last request first, this comes next.",
+		msgSomethingWrong:      "Something went wrong. Please try again or send /new.",
+		msgAdminOnly:           "This command is for admins only.",
+		msgBroadcastUsage:      "Usage: /broadcast <text>",
+		msgBroadcastSent:       "Broadcast sent to %d users, %d failed.",
+		msgNeedCategory:        "Pick a category first with /new.",
+		msgChooseAngle:         "Choose an angle, or send /angle <your own>:",
+		msgTapTopic:            "Please tap a topic above or type your own.",
+		msgPart:                "Part %d/%d",
+		msgReview:              "Happy with the script? Approve it to get the audio.",
+		msgNotReviewing:        "This script is no longer awaiting review. Send /new to start again.",
+		msgSendEdit:            "Send the corrected script as a message.",
+		msgEditAsText:          "Please send the corrected script as text.",
+		msgNothingToRegenerate: "Nothing to regenerate yet. Send /new to create a podcast.",
+		msgRegenerateWait:      "Please wait %d more seconds before regenerating.",
+		msgJobTopics:           "finding topics",
+		msgJobScript:           "writing your script",
+		msgJobAudio:            "recording the audio",
+		msgIdle:                "Idle: nothing is being generated. Send /new to create a podcast.",
+		msgBusy:                "⏳ Generating: %s (%ds so far). Send /cancel to stop.",
+		msgNoPodcasts:          "No podcasts yet. Send /new to create one.",
+		msgHistory:             "Your recent podcasts:",
+		msgHistoryHint:         "Send /replay <number> to get one again.",
+		msgReplayUsage:         "Send /replay <number> using a number from /history.",
+		msgReplayAudio:         "Want the audio again?",
+		msgStats:               "Podcasts created: %d\nAudio generated: %s",
+		msgStatsCategory:       "Favorite category: %s",
+		msgStatsTokens:         "AI tokens used: %d",
+		msgFavSaved:            "Saved to /favorites.",
+		msgFavNothing:          "Nothing to save yet.",
+		msgFavExists:           "Already in /favorites.",
+		msgFavFull:             "Favorites are full. Remove one in /favorites first.",
+		msgNoFavorites:         "No favorites yet. Tap ⭐ Save topic under a podcast to add one.",
+		msgFavorites:           "Your favorite topics:",
+		msgNoStorage:           "Episode storage is not enabled on this bot.",
+		msgArchiveTooBig:       "Your archive is over Telegram's 50 MB limit, so it can't be sent.",
+		msgArchiveFailed:       "Couldn't build your archive, please try again later.",
+		msgNoFeed:              "The podcast feed is not enabled on this bot.",
+		msgFeedLink:            "Add this URL to your podcast app. Keep it private: anyone with it can listen.\n\n%s",
+		msgFeedbackThanks:      "Thanks for your feedback!",
+		msgFeedbackUsage:       "Send /feedback followed by your comment, e.g. /feedback The intro was too long.",
+		msgFeedbackFrom:        "💬 Feedback from %s:\n\n%s",
+		msgConsent:             "Expressive narration lets the AI voice act out emotion and style. The voice is synthetic and generated by AI; it does not belong to a real person. Enable expressive narration?",
+		msgExpressiveOn:        "Expressive narration enabled for your next podcasts.",
+		msgExpressiveOff:       "Expressive narration disabled. Send /expressive to change your mind.",
+		msgExpressiveNoOptIn:   "Expressive narration needs no opt-in on this bot.",
+		msgChooseQuality:       "Choose the narration quality. HD sounds better but takes longer and costs more.",
+		msgQualityPinned:       "Expressive narration is on, so your podcasts use gpt-4o-mini-tts whichever you choose.",
+		msgQualityStandard:     "Podcasts will use standard quality.",
+		msgQualityHD:           "Podcasts will use HD quality.",
+		msgQualityOverridden:   "Expressive narration overrides it while it is on.",
+		msgChooseFormat:        "How should podcasts be delivered?",
+		msgFormatAudio:         "Podcasts will arrive as audio files.",
+		msgFormatVoice:         "Podcasts will arrive as voice messages.",
+		msgChooseLanguage:      "Choose the language for topics and scripts:",
+		msgLanguageSet:         "Language set to %s.",
+		msgChooseLength:        "Choose podcast length:",
+		msgLengthSet:           "Length set to %s.",
+		msgChooseVoice:         "Choose a narrator voice:",
+		msgVoiceSet:            "Voice set to %s.",
+		msgChooseSpeed:         "Choose a narration speed, or send /speed <%g-%g>:",
+		msgBadSpeed:            "Speed must be a number from %g to %g, e.g. /speed 1.25.",
+		msgSpeedSet:            "Speed set to %gx.",
+		msgStyles:              "Available styles: %s. Send /style <name> or tap one:",
+		msgUnknownStyle:        "Unknown style. Available styles: %s.",
+		msgStyleCleared:        "Style cleared.",
+		msgStyleSet:            "Style set to %s for your next podcasts.",
+		msgChooseOption:        "Choose an option:",
+		msgShowHeader:          "Your show:",
+		msgSettingsHeader:      "Your settings:",
+		msgShowSummary:         "Voice: %s\nStyle: %s\nLanguage: %s\nSpeed: %gx",
+		msgNoStyle:             "none",
+		msgSettingsSummary:     "Length: %s\nDelivery: %s\nQuality: %s",
+		msgAudioFile:           "Audio file",
+		msgVoiceMessage:        "Voice message",
+		msgStandard:            "Standard",
+		msgExpressiveSetting:   "Expressive narration: %s",
+		msgOn:                  "on",
+		msgOff:                 "off",
+		msgSettingsHint:        "Tap a setting to change it. Settings are kept across /new.",
+		msgHelpIntro: `I turn a topic into a short podcast:
+
+1. Send /new and pick a category.
+2. Pick a suggested topic or type your own.
+3. Review the script, then approve it to receive the audio.
+
+Commands:`,
+	},
+	"es": {
+		msgChooseCategory:      "Elige la categoría del podcast:",
+		msgChooseTopic:         "Elige un tema o escribe el tuyo:",
+		msgGenerating:          "🎙 Generando tu podcast...",
+		msgRecording:           "🎙 Grabando tu podcast...",
+		msgCaption:             "¡Aquí tienes tu podcast, disfrútalo!",
+		msgNoScript:            "No hay ningún guion. ¡Crea un podcast primero!",
+		msgCancelled:           "Cancelado. Envía /new para empezar de nuevo.",
+		msgError:               "Error al generar el contenido. Inténtalo de nuevo.",
+		msgAITimeout:           "La IA tardó demasiado, inténtalo de nuevo.",
+		msgAINoChoices:         "La IA no devolvió ninguna respuesta, quizá por el filtro de contenido. Prueba con otro tema.",
+		msgUnavailable:         "Servicio no disponible temporalmente, inténtalo más tarde.",
+		msgBudgetReached:       "Se alcanzó el límite diario, inténtalo mañana.",
+		msgRateLimited:         "Vas demasiado rápido, espera un momento",
+		msgUnauthorized:        "No tienes permiso para usar este bot.",
+		msgStaleButton:         "Este botón ya no está activo: envía /new para empezar de nuevo.",
+		msgQueued:              "⏳ Primero termino tu petición anterior, esto va después.",
+		msgSomethingWrong:      "Algo salió mal. Inténtalo de nuevo o envía /new.",
+		msgAdminOnly:           "Este comando es solo para administradores.",
+		msgBroadcastUsage:      "Uso: /broadcast <texto>",
+		msgBroadcastSent:       "Difusión enviada a %d usuarios, %d fallaron.",
+		msgNeedCategory:        "Primero elige una categoría con /new.",
+		msgChooseAngle:         "Elige un enfoque o envía /angle <el tuyo>:",
+		msgTapTopic:            "Toca uno de los temas de arriba o escribe el tuyo.",
+		msgPart:                "Parte %d/%d",
+		msgReview:              "¿Te gusta el guion? Apruébalo para recibir el audio.",
+		msgNotReviewing:        "Este guion ya no está pendiente de revisión. Envía /new para empezar de nuevo.",
+		msgSendEdit:            "Envía el guion corregido como mensaje.",
+		msgEditAsText:          "Envía el guion corregido como texto.",
+		msgNothingToRegenerate: "Todavía no hay nada que regenerar. Envía /new para crear un podcast.",
+		msgRegenerateWait:      "Espera %d segundos más antes de regenerar.",
+		msgJobTopics:           "buscando temas",
+		msgJobScript:           "escribiendo tu guion",
+		msgJobAudio:            "grabando el audio",
+		msgIdle:                "Inactivo: no se está generando nada. Envía /new para crear un podcast.",
+		msgBusy:                "⏳ Generando: %s (%d s hasta ahora). Envía /cancel para detenerlo.",
+		msgNoPodcasts:          "Aún no hay podcasts. Envía /new para crear uno.",
+		msgHistory:             "Tus podcasts recientes:",
+		msgHistoryHint:         "Envía /replay <número> para recibir uno otra vez.",
+		msgReplayUsage:         "Envía /replay <número> con un número de /history.",
+		msgReplayAudio:         "¿Quieres el audio otra vez?",
+		msgStats:               "Podcasts creados: %d\nAudio generado: %s",
+		msgStatsCategory:       "Categoría favorita: %s",
+		msgStatsTokens:         "Tokens de IA usados: %d",
+		msgFavSaved:            "Guardado en /favorites.",
+		msgFavNothing:          "Todavía no hay nada que guardar.",
+		msgFavExists:           "Ya está en /favorites.",
+		msgFavFull:             "Tus favoritos están llenos. Quita uno en /favorites primero.",
+		msgNoFavorites:         "Aún no tienes favoritos. Toca ⭐ Save topic bajo un podcast para añadir uno.",
+		msgFavorites:           "Tus temas favoritos:",
+		msgNoStorage:           "Este bot no tiene activado el almacenamiento de episodios.",
+		msgArchiveTooBig:       "Tu archivo supera el límite de 50 MB de Telegram, así que no se puede enviar.",
+		msgArchiveFailed:       "No se pudo crear tu archivo, inténtalo más tarde.",
+		msgNoFeed:              "Este bot no tiene activado el feed de podcasts.",
+		msgFeedLink:            "Añade esta URL a tu app de podcasts. Mantenla en privado: cualquiera que la tenga puede escuchar.\n\n%s",
+		msgFeedbackThanks:      "¡Gracias por tus comentarios!",
+		msgFeedbackUsage:       "Envía /feedback seguido de tu comentario, p. ej. /feedback La introducción era demasiado larga.",
+		msgFeedbackFrom:        "💬 Comentario de %s:\n\n%s",
+		msgConsent:             "La narración expresiva permite que la voz de IA interprete emociones y estilo. La voz es sintética y está generada por IA; no pertenece a una persona real. ¿Activar la narración expresiva?",
+		msgExpressiveOn:        "Narración expresiva activada para tus próximos podcasts.",
+		msgExpressiveOff:       "Narración expresiva desactivada. Envía /expressive si cambias de opinión.",
+		msgExpressiveNoOptIn:   "En este bot la narración expresiva no requiere aceptación.",
+		msgChooseQuality:       "Elige la calidad de la narración. HD suena mejor, pero tarda más y cuesta más.",
+		msgQualityPinned:       "La narración expresiva está activada, así que tus podcasts usan gpt-4o-mini-tts elijas lo que elijas.",
+		msgQualityStandard:     "Los podcasts usarán calidad estándar.",
+		msgQualityHD:           "Los podcasts usarán calidad HD.",
+		msgQualityOverridden:   "La narración expresiva la sustituye mientras esté activada.",
+		msgChooseFormat:        "¿Cómo quieres recibir los podcasts?",
+		msgFormatAudio:         "Los podcasts llegarán como archivos de audio.",
+		msgFormatVoice:         "Los podcasts llegarán como mensajes de voz.",
+		msgChooseLanguage:      "Elige el idioma de los temas y los guiones:",
+		msgLanguageSet:         "Idioma cambiado a %s.",
+		msgChooseLength:        "Elige la duración del podcast:",
+		msgLengthSet:           "Duración cambiada a %s.",
+		msgChooseVoice:         "Elige la voz del narrador:",
+		msgVoiceSet:            "Voz cambiada a %s.",
+		msgChooseSpeed:         "Elige la velocidad de narración o envía /speed <%g-%g>:",
+		msgBadSpeed:            "La velocidad debe ser un número de %g a %g, p. ej. /speed 1.25.",
+		msgSpeedSet:            "Velocidad cambiada a %gx.",
+		msgStyles:              "Estilos disponibles: %s. Envía /style <nombre> o toca uno:",
+		msgUnknownStyle:        "Estilo desconocido. Estilos disponibles: %s.",
+		msgStyleCleared:        "Estilo quitado.",
+		msgStyleSet:            "Estilo cambiado a %s para tus próximos podcasts.",
+		msgChooseOption:        "Elige una opción:",
+		msgShowHeader:          "Tu programa:",
+		msgSettingsHeader:      "Tus ajustes:",
+		msgShowSummary:         "Voz: %s\nEstilo: %s\nIdioma: %s\nVelocidad: %gx",
+		msgNoStyle:             "ninguno",
+		msgSettingsSummary:     "Duración: %s\nEntrega: %s\nCalidad: %s",
+		msgAudioFile:           "Archivo de audio",
+		msgVoiceMessage:        "Mensaje de voz",
+		msgStandard:            "Estándar",
+		msgExpressiveSetting:   "Narración expresiva: %s",
+		msgOn:                  "activada",
+		msgOff:                 "desactivada",
+		msgSettingsHint:        "Toca un ajuste para cambiarlo. Los ajustes se mantienen al usar /new.",
+		msgHelpIntro: `Convierto un tema en un podcast corto:
+
+1. Envía /new y elige una categoría.
+2. Elige un tema sugerido o escribe el tuyo.
+3. Revisa el guion y apruébalo para recibir el audio.
+
+Comandos:`,
+	},
+}
+
+// messageLang returns the language of the user's messages: the one chosen
+// with /language, or else Config.SpeechLang.
+func (b *Bot) messageLang(userID int64) string {
+	lang := b.cfg.SpeechLang
+	b.mu.Lock()
+	// Look the state up directly: unknown senders must not get one.
+	if st := b.states[userID]; st != nil && st.Prefs.Show.Language != "" {
+		lang = st.Prefs.Show.Language
+	}
+	b.mu.Unlock()
+	return lang
+}
+
+// localized returns the message for key in the user's language.
+func (b *Bot) localized(userID int64, key string) string {
+	lang := b.messageLang(userID)
+	if s, ok := messages[lang][key]; ok {
+		return s
+	}
+	return messages["en"][key]
+}
+
+// localizedf formats the message for key, a fmt format, with args.
+func (b *Bot) localizedf(userID int64, key string, args ...any) string {
+	return fmt.Sprintf(b.localized(userID, key), args...)
+}
@@ -0,0 +1,166 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ttsBuckets are the upper bounds, in seconds, of the TTS latency histogram.
+var ttsBuckets = []float64{1, 2, 5, 10, 20, 30, 60, 120}
+
+// Metrics counts bot activity and serves it in the Prometheus text format.
+// A nil *Metrics is valid and records nothing.
+type Metrics struct {
+	mu       sync.Mutex
+	podcasts uint64
+	topics   uint64
+	aiErrors map[string]uint64
+
+	promptTokens, completionTokens uint64
+
+	ttsCounts []uint64
+	ttsSum    float64
+	ttsCount  uint64
+}
+
+// NewMetrics creates an empty Metrics.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		aiErrors:  make(map[string]uint64),
+		ttsCounts: make([]uint64, len(ttsBuckets)),
+	}
+}
+
+func (m *Metrics) podcastGenerated() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.podcasts++
+	m.mu.Unlock()
+}
+
+func (m *Metrics) topicsGenerated() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.topics++
+	m.mu.Unlock()
+}
+
+func (m *Metrics) aiTokens(prompt, completion int) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.promptTokens += uint64(prompt)
+	m.completionTokens += uint64(completion)
+	m.mu.Unlock()
+}
+
+func (m *Metrics) aiError(err error) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.aiErrors[aiErrorType(err)]++
+	m.mu.Unlock()
+}
+
+func (m *Metrics) observeTTS(d time.Duration) {
+	if m == nil {
+		return
+	}
+	secs := d.Seconds()
+	m.mu.Lock()
+	for i, le := range ttsBuckets {
+		if secs <= le {
+			m.ttsCounts[i]++
+		}
+	}
+	m.ttsSum += secs
+	m.ttsCount++
+	m.mu.Unlock()
+}
+
+// aiErrorType labels an OpenAI error for the error counter.
+func aiErrorType(err error) string {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case isQuotaError(err):
+		return "quota"
+	case isRetryable(err):
+		return "retryable"
+	case errors.Is(err, ErrNoChoices):
+		return "no_choices"
+	default:
+		return "other"
+	}
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var sb strings.Builder
+	writeCounter(&sb, "podcaster_podcasts_generated_total", "Podcasts delivered to users.", m.podcasts)
+	writeCounter(&sb, "podcaster_topics_generated_total", "Topic lists generated.", m.topics)
+
+	sb.WriteString("# HELP podcaster_openai_errors_total Failed OpenAI calls by error type.\n")
+	sb.WriteString("# TYPE podcaster_openai_errors_total counter\n")
+	types := make([]string, 0, len(m.aiErrors))
+	for t := range m.aiErrors {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	for _, t := range types {
+		fmt.Fprintf(&sb, "podcaster_openai_errors_total{type=%q} %d\n", t, m.aiErrors[t])
+	}
+
+	sb.WriteString("# HELP podcaster_openai_tokens_total Chat completion tokens used, by kind.\n")
+	sb.WriteString("# TYPE podcaster_openai_tokens_total counter\n")
+	fmt.Fprintf(&sb, "podcaster_openai_tokens_total{kind=\"prompt\"} %d\n", m.promptTokens)
+	fmt.Fprintf(&sb, "podcaster_openai_tokens_total{kind=\"completion\"} %d\n", m.completionTokens)
+
+	sb.WriteString("# HELP podcaster_tts_duration_seconds Time spent generating speech.\n")
+	sb.WriteString("# TYPE podcaster_tts_duration_seconds histogram\n")
+	for i, le := range ttsBuckets {
+		fmt.Fprintf(&sb, "podcaster_tts_duration_seconds_bucket{le=\"%g\"} %d\n", le, m.ttsCounts[i])
+	}
+	fmt.Fprintf(&sb, "podcaster_tts_duration_seconds_bucket{le=\"+Inf\"} %d\n", m.ttsCount)
+	fmt.Fprintf(&sb, "podcaster_tts_duration_seconds_sum %g\n", m.ttsSum)
+	fmt.Fprintf(&sb, "podcaster_tts_duration_seconds_count %d\n", m.ttsCount)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.Write([]byte(sb.String()))
+}
+
+func writeCounter(sb *strings.Builder, name, help string, v uint64) {
+	fmt.Fprintf(sb, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", name, help, name, name, v)
+}
+
+// serveMetrics exposes /metrics on addr until ctx is done.
+func (b *Bot) serveMetrics(ctx context.Context, addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", b.metrics)
+	srv := &http.Server{Addr: addr, Handler: mux}
+
+	go func() {
+		<-ctx.Done()
+		srv.Shutdown(context.Background())
+	}()
+
+	b.log.Info("serving metrics", "addr", addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		b.log.Error("metrics server", "err", err)
+	}
+}
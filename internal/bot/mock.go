@@ -0,0 +1,89 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"strings"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// MockAI is an AIClient that returns canned topics, scripts and silent
+// audio without calling OpenAI, for local development and demos.
+type MockAI struct{}
+
+var mockTopics = []string{
+	"The history of everyday things",
+	"Myths experts wish would die",
+	"What the next decade might bring",
+	"A beginner's guide in five minutes",
+	"The surprising science behind it",
+}
+
+// CreateChatCompletion answers topic prompts with mockTopics, title prompts
+// with a fixed title and anything else with a short script, structured if
+// the prompt asks for sections or a dialogue.
+func (MockAI) CreateChatCompletion(_ context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	prompt := req.Messages[len(req.Messages)-1].Content
+
+	var reply string
+	switch {
+	case strings.HasPrefix(prompt, "Generate ") && strings.Contains(prompt, " podcast topics about "):
+		data, _ := json.Marshal(map[string][]string{"topics": mockTopics})
+		reply = string(data)
+	case strings.HasPrefix(prompt, titlePromptPrefix):
+		reply = "A Mock Episode Worth Hearing"
+	case strings.Contains(prompt, dialoguePrompt):
+		reply = "ALEX: Welcome to a mock episode of the show.\n" +
+			"SAM: This conversation was written without calling OpenAI.\n" +
+			"ALEX: Every step of the bot still runs as usual.\n" +
+			"SAM: Thanks for listening!"
+	case strings.Contains(prompt, segmentsPrompt):
+		reply = "INTRO\nWelcome to a mock episode of the show.\n\n" +
+			"POINT 1\nThis script was written without calling OpenAI.\n\n" +
+			"POINT 2\nEvery step of the bot still runs as usual.\n\n" +
+			"OUTRO\nThanks for listening!"
+	default:
+		reply = fmt.Sprintf("Welcome to a mock episode. You asked: %q\n\n"+
+			"This script was written without calling OpenAI, so no credits were spent. "+
+			"Thanks for listening!", truncateLog(prompt))
+	}
+
+	return openai.ChatCompletionResponse{
+		Model:   req.Model,
+		Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: reply}}},
+	}, nil
+}
+
+// CreateSpeech returns two seconds of silent MP3, whatever format was
+// requested.
+func (MockAI) CreateSpeech(context.Context, openai.CreateSpeechRequest) (openai.RawResponse, error) {
+	return openai.RawResponse{ReadCloser: io.NopCloser(bytes.NewReader(silentMP3(2)))}, nil
+}
+
+// CreateImage returns a blank square PNG.
+func (MockAI) CreateImage(context.Context, openai.ImageRequest) (openai.ImageResponse, error) {
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewGray(image.Rect(0, 0, 64, 64)))
+	return openai.ImageResponse{
+		Data: []openai.ImageResponseDataInner{{B64JSON: base64.StdEncoding.EncodeToString(buf.Bytes())}},
+	}, nil
+}
+
+// silentMP3 builds seconds of silence as 32 kbps, 44.1 kHz mono MPEG-1
+// Layer III frames. All-zero side info decodes as silence.
+func silentMP3(seconds int) []byte {
+	const (
+		frameSize       = 144 * 32000 / 44100 // 104 bytes, no padding
+		framesPerSecond = 44100 / 1152
+	)
+	frame := make([]byte, frameSize)
+	copy(frame, []byte{0xFF, 0xFB, 0x10, 0xC0})
+	return bytes.Repeat(frame, seconds*(framesPerSecond+1))
+}
@@ -0,0 +1,121 @@
+package bot
+
+import (
+	"sync"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// workerQueueSize caps how many updates may wait behind one user's
+// running update; later ones are dropped.
+const workerQueueSize = 64
+
+// pool runs update handlers with at most size running at once. Each user
+// has their own queue, so their updates run in order, while a user busy
+// with a long generation holds up nobody else.
+type pool struct {
+	handle func(tgbotapi.Update)
+	slots  chan struct{}
+	wg     sync.WaitGroup
+
+	mu     sync.Mutex
+	queues map[int64][]tgbotapi.Update
+	closed bool
+}
+
+func newPool(size int, handle func(tgbotapi.Update)) *pool {
+	return &pool{
+		handle: handle,
+		slots:  make(chan struct{}, size),
+		queues: make(map[int64][]tgbotapi.Update),
+	}
+}
+
+// busy reports whether an update of userID is running or queued, so a new
+// one would have to wait.
+func (p *pool) busy(userID int64) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.queues[userID]
+	return ok
+}
+
+// dispatch queues u behind its user's earlier updates. It reports false if
+// u was dropped because the pool is stopped or the user's queue is full.
+func (p *pool) dispatch(u tgbotapi.Update) bool {
+	userID, _ := updateUserID(u)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	q, running := p.queues[userID]
+	if p.closed || len(q) >= workerQueueSize {
+		return false
+	}
+	p.queues[userID] = append(q, u)
+	if !running {
+		p.wg.Add(1)
+		go p.run(userID)
+	}
+	return true
+}
+
+// dispatchNow handles u right away, beside its user's queue and without
+// waiting for a slot, for quick read-only updates that must not wait
+// behind a generation. Like queued updates, stop waits for it. It reports
+// false if the pool is stopped.
+func (p *pool) dispatchNow(u tgbotapi.Update) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return false
+	}
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.handle(u)
+	}()
+	return true
+}
+
+// run handles userID's queue until it is empty.
+func (p *pool) run(userID int64) {
+	defer p.wg.Done()
+	for {
+		p.mu.Lock()
+		q := p.queues[userID]
+		if len(q) == 0 || p.closed {
+			delete(p.queues, userID)
+			p.mu.Unlock()
+			return
+		}
+		u := q[0]
+		p.queues[userID] = q[1:]
+		p.mu.Unlock()
+
+		p.slots <- struct{}{}
+		p.handle(u)
+		<-p.slots
+	}
+}
+
+// stop drops the queued updates and waits for the running ones to finish.
+func (p *pool) stop() {
+	p.mu.Lock()
+	p.closed = true
+	for userID := range p.queues {
+		p.queues[userID] = nil
+	}
+	p.mu.Unlock()
+	p.wg.Wait()
+}
+
+// updateUserID returns the chat an update belongs to.
+func updateUserID(u tgbotapi.Update) (int64, bool) {
+	switch {
+	case u.Message != nil:
+		return u.Message.Chat.ID, true
+	case u.CallbackQuery != nil:
+		return callbackChatID(u.CallbackQuery)
+	}
+	return 0, false
+}
@@ -0,0 +1,273 @@
+package bot
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// userText is a message from userID saying s.
+func userText(userID int64, s string) tgbotapi.Update {
+	u := text(s)
+	u.Message.Chat.ID, u.Message.From.ID = userID, userID
+	return u
+}
+
+func TestPoolKeepsEachUsersOrder(t *testing.T) {
+	var (
+		mu  sync.Mutex
+		got = map[int64][]string{}
+		wg  sync.WaitGroup
+	)
+	p := newPool(4, func(u tgbotapi.Update) {
+		defer wg.Done()
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		got[u.Message.Chat.ID] = append(got[u.Message.Chat.ID], u.Message.Text)
+		mu.Unlock()
+	})
+	defer p.stop()
+
+	want := []string{"a", "b", "c", "d", "e"}
+	for _, s := range want {
+		for user := int64(1); user <= 3; user++ {
+			wg.Add(1)
+			p.dispatch(userText(user, s))
+		}
+	}
+	wg.Wait()
+	for user := int64(1); user <= 3; user++ {
+		if len(got[user]) != len(want) {
+			t.Fatalf("user %d: handled %q, want %q", user, got[user], want)
+		}
+		for i := range want {
+			if got[user][i] != want[i] {
+				t.Errorf("user %d: handled %q, want %q", user, got[user], want)
+				break
+			}
+		}
+	}
+}
+
+func TestPoolDoesNotSerializeUsers(t *testing.T) {
+	slow, release := make(chan struct{}), make(chan struct{})
+	fast := make(chan struct{})
+	p := newPool(2, func(u tgbotapi.Update) {
+		if u.Message.Chat.ID == 1 {
+			close(slow)
+			<-release
+			return
+		}
+		close(fast)
+	})
+	defer p.stop()
+	defer close(release)
+
+	p.dispatch(userText(1, "slow"))
+	<-slow
+	p.dispatch(userText(2, "fast"))
+	select {
+	case <-fast:
+	case <-time.After(5 * time.Second):
+		t.Fatal("a slow user held up another")
+	}
+	if !p.busy(1) || p.busy(3) {
+		t.Errorf("busy(1) = %v, busy(3) = %v", p.busy(1), p.busy(3))
+	}
+}
+
+func TestPoolLimitsRunningHandlers(t *testing.T) {
+	const size = 2
+	var (
+		mu            sync.Mutex
+		running, most int
+		wg            sync.WaitGroup
+	)
+	p := newPool(size, func(tgbotapi.Update) {
+		defer wg.Done()
+		mu.Lock()
+		running++
+		most = max(most, running)
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+	})
+	defer p.stop()
+	for user := int64(1); user <= 3*size; user++ {
+		wg.Add(1)
+		p.dispatch(userText(user, "hi"))
+	}
+	wg.Wait()
+	if most != size {
+		t.Errorf("at most %d handlers ran at once, want %d", most, size)
+	}
+}
+
+func TestPoolStopDropsQueuedUpdates(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	var (
+		mu      sync.Mutex
+		handled []string
+	)
+	p := newPool(1, func(u tgbotapi.Update) {
+		mu.Lock()
+		handled = append(handled, u.Message.Text)
+		mu.Unlock()
+		if u.Message.Text == "first" {
+			close(started)
+			<-release
+		}
+	})
+	p.dispatch(userText(1, "first"))
+	<-started
+	p.dispatch(userText(1, "queued"))
+
+	stopped := make(chan struct{})
+	go func() {
+		p.stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		t.Fatal("stop returned while a handler was running")
+	case <-time.After(10 * time.Millisecond):
+	}
+	close(release)
+	<-stopped
+
+	if p.dispatch(userText(1, "late")) {
+		t.Error("a stopped pool accepted an update")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(handled) != 1 {
+		t.Errorf("handled %q, want only the running update", handled)
+	}
+}
+
+func TestPoolDispatchNow(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	statusDone, statusRelease := make(chan struct{}), make(chan struct{})
+	p := newPool(1, func(u tgbotapi.Update) {
+		switch u.Message.Text {
+		case "generate":
+			close(started)
+			<-release
+		case "status":
+			close(statusDone)
+			<-statusRelease
+		}
+	})
+	defer close(release)
+
+	p.dispatch(userText(1, "generate"))
+	<-started
+	// The user and the only slot are busy, but the fast lane runs anyway.
+	if !p.dispatchNow(userText(1, "status")) {
+		t.Fatal("dispatchNow refused an update")
+	}
+	select {
+	case <-statusDone:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the fast lane waited behind the running update")
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		p.stop()
+		close(stopped)
+	}()
+	release <- struct{}{}
+	select {
+	case <-stopped:
+		t.Fatal("stop returned while a fast-lane handler was running")
+	case <-time.After(10 * time.Millisecond):
+	}
+	close(statusRelease)
+	<-stopped
+
+	if p.dispatchNow(userText(1, "late")) {
+		t.Error("a stopped pool accepted a fast-lane update")
+	}
+}
+
+func TestPoolCapsEachQueue(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	p := newPool(1, func(tgbotapi.Update) {
+		once.Do(func() { close(started) })
+		<-release
+	})
+	defer p.stop()
+	defer close(release)
+
+	p.dispatch(userText(1, "running"))
+	<-started
+	for i := 0; i < workerQueueSize; i++ {
+		if !p.dispatch(userText(1, "queued")) {
+			t.Fatalf("update %d was dropped below the cap", i+1)
+		}
+	}
+	if p.dispatch(userText(1, "over")) {
+		t.Error("a full queue accepted an update")
+	}
+	if !p.dispatch(userText(2, "other")) {
+		t.Error("another user's update was dropped")
+	}
+}
+
+func TestRunAnswersTapsQueuedBehindAJob(t *testing.T) {
+	b, tg, ai := newTestBot(t, Config{})
+	tg.updates = make(chan tgbotapi.Update)
+	started, release := make(chan struct{}), make(chan struct{})
+	ai.chat = func(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+		close(started)
+		<-release
+		return ai.MockAI.CreateChatCompletion(ctx, req)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- b.Run(ctx) }()
+
+	tg.updates <- command("new")
+	tg.updates <- tap(categoryPrefix + DefaultCategories[0])
+	<-started
+	waiting := tap(settingsPrefix + "length")
+	waiting.CallbackQuery.ID = "waiting"
+	tg.updates <- waiting
+
+	deadline := time.After(5 * time.Second)
+	for !slices.Contains(tg.callbackAnswers(), messages["en"][msgQueued]) {
+		select {
+		case <-deadline:
+			t.Fatalf("answers = %q, want the queued notice while the job runs", tg.callbackAnswers())
+		case <-time.After(time.Millisecond):
+		}
+	}
+	close(release)
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	// The queued tap is not answered a second time once it runs.
+	var count int
+	tg.mu.Lock()
+	for _, c := range tg.requests {
+		if cb, ok := c.(tgbotapi.CallbackConfig); ok && cb.CallbackQueryID == "waiting" {
+			count++
+		}
+	}
+	tg.mu.Unlock()
+	if count > 1 {
+		t.Errorf("answered the queued tap %d times", count)
+	}
+}
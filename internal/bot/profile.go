@@ -0,0 +1,181 @@
+package bot
+
+import (
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+const showPrefix = "show:"
+
+// ShowProfile is a user's persistent "show identity", applied to every
+// generation so their episodes sound consistent. Zero values mean defaults.
+type ShowProfile struct {
+	Voice    openai.SpeechVoice
+	Style    string
+	Language string
+	Speed    float64
+}
+
+// language is a script language a show can be narrated in.
+type language struct {
+	Code string
+	Name string
+}
+
+var (
+	showVoices = []openai.SpeechVoice{
+		openai.VoiceAlloy, openai.VoiceEcho, openai.VoiceFable,
+		openai.VoiceOnyx, openai.VoiceNova, openai.VoiceShimmer,
+	}
+	showLanguages = []language{
+		{"en", "English"}, {"es", "Spanish"}, {"de", "German"},
+		{"fr", "French"}, {"it", "Italian"}, {"ru", "Russian"},
+	}
+	showSpeeds = []float64{0.75, 1, 1.25, 1.5}
+)
+
+func (b *Bot) profile(userID int64) ShowProfile {
+	st := b.getState(userID)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return st.Prefs.Show
+}
+
+// voice returns the profile voice, falling back to Alloy.
+func (p ShowProfile) voice() openai.SpeechVoice {
+	if p.Voice == "" {
+		return openai.VoiceAlloy
+	}
+	return p.Voice
+}
+
+func (p ShowProfile) languageName() string {
+	for _, l := range showLanguages {
+		if l.Code == p.Language {
+			return l.Name
+		}
+	}
+	return "English"
+}
+
+// describeShow lists the user's show fields under header, a message key.
+func (b *Bot) describeShow(userID int64, header string) string {
+	p := b.profile(userID)
+	style := p.Style
+	if style == "" {
+		style = b.localized(userID, msgNoStyle)
+	}
+	return b.localized(userID, header) + "\n" +
+		b.localizedf(userID, msgShowSummary, p.voice(), style, p.languageName(), p.speed())
+}
+
+// sendShowProfile shows the user's show identity with buttons to edit it.
+func (b *Bot) sendShowProfile(userID int64) {
+	msg := tgbotapi.NewMessage(userID, b.describeShow(userID, msgShowHeader))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🎙 Voice", showPrefix+"voice"),
+			tgbotapi.NewInlineKeyboardButtonData("🎭 Style", showPrefix+"style"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🌐 Language", showPrefix+"lang"),
+			tgbotapi.NewInlineKeyboardButtonData("⏩ Speed", showPrefix+"speed"),
+		),
+	)
+	b.tg.Send(msg)
+}
+
+// handleShowCallback handles "<field>" (list options) and "<field>:<value>"
+// (apply option) actions from the /myshow keyboard.
+func (b *Bot) handleShowCallback(userID int64, action string) {
+	field, value, set := strings.Cut(action, ":")
+	if !set {
+		b.sendShowOptions(userID, field)
+		return
+	}
+
+	st := b.getState(userID)
+	b.mu.Lock()
+	ok := applyShowOption(&st.Prefs.Show, field, value)
+	b.mu.Unlock()
+	if ok {
+		b.sendShowProfile(userID)
+	}
+}
+
+func applyShowOption(p *ShowProfile, field, value string) bool {
+	switch field {
+	case "voice":
+		for _, v := range showVoices {
+			if string(v) == value {
+				p.Voice = v
+				return true
+			}
+		}
+	case "style":
+		if value == "none" {
+			p.Style = ""
+			return true
+		}
+		if s, ok := findStyle(value); ok {
+			p.Style = s.Name
+			return true
+		}
+	case "lang":
+		for _, l := range showLanguages {
+			if l.Code == value {
+				p.Language = l.Code
+				return true
+			}
+		}
+	case "speed":
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return false
+		}
+		for _, s := range showSpeeds {
+			if s == f {
+				p.Speed = f
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (b *Bot) sendShowOptions(userID int64, field string) {
+	var buttons []tgbotapi.InlineKeyboardButton
+	add := func(label, value string) {
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(label, showPrefix+field+":"+value))
+	}
+
+	switch field {
+	case "voice":
+		for _, v := range showVoices {
+			add(string(v), string(v))
+		}
+	case "style":
+		add("none", "none")
+		for _, s := range stylePresets {
+			add(s.Name, s.Name)
+		}
+	case "lang":
+		for _, l := range showLanguages {
+			add(l.Name, l.Code)
+		}
+	case "speed":
+		for _, s := range showSpeeds {
+			v := strconv.FormatFloat(s, 'g', -1, 64)
+			add(v+"x", v)
+		}
+	default:
+		return
+	}
+
+	msg := tgbotapi.NewMessage(userID, b.localized(userID, msgChooseOption))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(keyboardRows(buttons, 3)...)
+	b.tg.Send(msg)
+}
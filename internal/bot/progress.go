@@ -0,0 +1,54 @@
+package bot
+
+import (
+	"context"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// sendProgress posts a status message for a long operation and returns a
+// func that deletes it once the operation ends. A failed delete, e.g.
+// because the user already removed the message, is only logged.
+func (b *Bot) sendProgress(userID int64, text string) (remove func()) {
+	sent, err := b.tg.Send(tgbotapi.NewMessage(userID, text))
+	if err != nil {
+		return func() {}
+	}
+	return func() {
+		if _, err := b.tg.Request(tgbotapi.NewDeleteMessage(userID, sent.MessageID)); err != nil {
+			b.log.Debug("delete progress message", "user_id", userID, "err", err)
+		}
+	}
+}
+
+// chatActionInterval re-sends a chat action before Telegram hides it,
+// about five seconds after it was sent.
+const chatActionInterval = 4 * time.Second
+
+// showChatAction keeps action, e.g. tgbotapi.ChatTyping, visible in the
+// user's chat until the returned func is called or ctx ends. stop waits for
+// the last action to go out, so none lingers after the reply is sent.
+func (b *Bot) showChatAction(ctx context.Context, userID int64, action string) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		t := time.NewTicker(chatActionInterval)
+		defer t.Stop()
+		for {
+			if _, err := b.tg.Request(tgbotapi.NewChatAction(userID, action)); err != nil {
+				b.log.Debug("send chat action", "user_id", userID, "err", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-exited
+	}
+}
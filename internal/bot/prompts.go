@@ -0,0 +1,54 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// requiredPlaceholders must appear in every script template, so the topic
+// and word budget always reach the model.
+var requiredPlaceholders = []string{"{topic}", "{words}"}
+
+// validateTemplates checks the script templates at startup.
+func validateTemplates(cfg Config) error {
+	check := func(name, tmpl string) error {
+		for _, p := range requiredPlaceholders {
+			if !strings.Contains(tmpl, p) {
+				return fmt.Errorf("bot: %s template is missing %s", name, p)
+			}
+		}
+		return nil
+	}
+	if err := check("default script", cfg.ScriptTemplate); err != nil {
+		return err
+	}
+	for cat, tmpl := range cfg.CategoryTemplates {
+		if !cfg.isCategory(cat) {
+			return fmt.Errorf("bot: template for unknown category %q", cat)
+		}
+		if err := check(fmt.Sprintf("%q", cat), tmpl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scriptTemplate returns the template for category.
+func (b *Bot) scriptTemplate(category string) string {
+	if tmpl, ok := b.cfg.CategoryTemplates[category]; ok {
+		return tmpl
+	}
+	return b.cfg.ScriptTemplate
+}
+
+func scriptPrompt(tmpl, topic, category string, length podcastLength, p ShowProfile) string {
+	prompt := strings.NewReplacer(
+		"{topic}", topic,
+		"{category}", category,
+		"{minutes}", strconv.Itoa(length.Minutes),
+		"{words}", strconv.Itoa(length.Words),
+	).Replace(tmpl)
+	// Always name the language, so the script matches what TTS will speak.
+	return prompt + fmt.Sprintf(" Write the script in %s.", p.languageName())
+}
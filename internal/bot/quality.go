@@ -0,0 +1,69 @@
+package bot
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+const qualityPrefix = "quality:"
+
+// Speech qualities a user can choose with /quality.
+const (
+	QualityStandard = "standard"
+	QualityHD       = "hd"
+)
+
+// speechModel returns the TTS model for the user's quality. Unknown stored
+// values fall back to the cheaper tts-1.
+func (b *Bot) speechModel(userID int64) openai.SpeechModel {
+	st := b.getState(userID)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if st.Prefs.Quality == QualityHD {
+		return openai.TTSModel1HD
+	}
+	return openai.TTSModel1
+}
+
+func (b *Bot) sendQualities(userID int64) {
+	current := b.speechModel(userID)
+	label := func(model openai.SpeechModel, text string) string {
+		if model == current {
+			return "✅ " + text
+		}
+		return text
+	}
+
+	text := b.localized(userID, msgChooseQuality)
+	if b.usesInstructionsModel(userID) {
+		text += "\n\n" + b.localized(userID, msgQualityPinned)
+	}
+	msg := tgbotapi.NewMessage(userID, text)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(label(openai.TTSModel1, "Standard"), qualityPrefix+QualityStandard),
+			tgbotapi.NewInlineKeyboardButtonData(label(openai.TTSModel1HD, "HD"), qualityPrefix+QualityHD),
+		),
+	)
+	b.tg.Send(msg)
+}
+
+func (b *Bot) handleQualitySelection(userID int64, quality string) {
+	if quality != QualityStandard && quality != QualityHD {
+		return
+	}
+
+	st := b.getState(userID)
+	b.mu.Lock()
+	st.Prefs.Quality = quality
+	b.mu.Unlock()
+
+	text := b.localized(userID, msgQualityStandard)
+	if quality == QualityHD {
+		text = b.localized(userID, msgQualityHD)
+	}
+	if b.usesInstructionsModel(userID) {
+		text += " " + b.localized(userID, msgQualityOverridden)
+	}
+	b.tg.Send(tgbotapi.NewMessage(userID, text))
+}
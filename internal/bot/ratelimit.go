@@ -0,0 +1,54 @@
+package bot
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimiter is a per-user token bucket refilling at perMinute tokens per
+// minute, with a burst of perMinute.
+type rateLimiter struct {
+	perMinute int
+
+	mu      sync.Mutex
+	buckets map[int64]*bucket
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(perMinute int) *rateLimiter {
+	return &rateLimiter{perMinute: perMinute, buckets: make(map[int64]*bucket)}
+}
+
+// allow reports whether userID may make a request at now, consuming a
+// token if so. A non-positive limit allows everything.
+func (l *rateLimiter) allow(userID int64, now time.Time) bool {
+	if l.perMinute <= 0 {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	capacity := float64(l.perMinute)
+	bk, ok := l.buckets[userID]
+	if !ok {
+		bk = &bucket{tokens: capacity, last: now}
+		l.buckets[userID] = bk
+	}
+
+	bk.tokens += now.Sub(bk.last).Minutes() * capacity
+	if bk.tokens > capacity {
+		bk.tokens = capacity
+	}
+	bk.last = now
+
+	if bk.tokens < 1 {
+		return false
+	}
+	bk.tokens--
+	return true
+}
@@ -0,0 +1,53 @@
+package bot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	l := newRateLimiter(3)
+	for i := 0; i < 3; i++ {
+		if !l.allow(testUser, now) {
+			t.Fatalf("request %d was rejected within the burst", i+1)
+		}
+	}
+	if l.allow(testUser, now) {
+		t.Error("an exhausted bucket allowed a request")
+	}
+	if !l.allow(testUser+1, now) {
+		t.Error("another user shares the bucket")
+	}
+	// Three per minute refill one token every 20 seconds.
+	if l.allow(testUser, now.Add(19*time.Second)) {
+		t.Error("a token came back early")
+	}
+	if !l.allow(testUser, now.Add(21*time.Second)) {
+		t.Error("no token came back after 20 seconds")
+	}
+}
+
+func TestRateLimiterDisabled(t *testing.T) {
+	l := newRateLimiter(0)
+	for i := 0; i < 100; i++ {
+		if !l.allow(testUser, time.Now()) {
+			t.Fatal("a zero limit rejected a request")
+		}
+	}
+}
+
+func TestRateLimitedUpdates(t *testing.T) {
+	b, tg, _ := newTestBot(t, Config{RateLimit: 2})
+	b.handleUpdate(command("help"))
+	b.handleUpdate(command("help"))
+	b.handleUpdate(command("help"))
+	if got := tg.texts(); got[len(got)-1] != messages["en"][msgRateLimited] {
+		t.Errorf("third message got %q, want the rate limit notice", got[len(got)-1])
+	}
+
+	b.handleUpdate(tap(settingsPrefix + "length"))
+	if got := tg.callbackAnswers(); len(got) != 1 || got[0] != messages["en"][msgRateLimited] {
+		t.Errorf("answers = %q, want the rate limit notice", got)
+	}
+}
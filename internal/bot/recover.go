@@ -0,0 +1,21 @@
+package bot
+
+import (
+	"runtime/debug"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// recoverUpdate stops a panic in an update handler from taking down the
+// worker, and with it the bot. It must be deferred directly.
+func (b *Bot) recoverUpdate(update tgbotapi.Update, userID int64, hasUser bool) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	b.log.Error("panic handling update", "user_id", userID, "update_id", update.UpdateID,
+		"panic", r, "stack", string(debug.Stack()))
+	if hasUser {
+		b.tg.Send(tgbotapi.NewMessage(userID, b.localized(userID, msgSomethingWrong)))
+	}
+}
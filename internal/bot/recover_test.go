@@ -0,0 +1,60 @@
+package bot
+
+import (
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestHandlerPanicsAreRecovered(t *testing.T) {
+	b, tg, _ := newTestBot(t, Config{})
+	panicking := true
+	tg.reject = func(c tgbotapi.Chattable) error {
+		if m, ok := c.(tgbotapi.MessageConfig); ok && panicking && m.Text == messages["en"][msgChooseCategory] {
+			panic("boom")
+		}
+		return nil
+	}
+
+	b.handleUpdate(command("new"))
+	if got := tg.texts(); len(got) != 1 || got[0] != messages["en"][msgSomethingWrong] {
+		t.Fatalf("after a panic sent %q, want the generic error", got)
+	}
+
+	// The next update is handled as usual.
+	panicking = false
+	b.handleUpdate(command("new"))
+	if got := tg.texts(); got[len(got)-1] != messages["en"][msgChooseCategory] {
+		t.Errorf("after recovering sent %q", got)
+	}
+}
+
+func TestPoolSurvivesPanics(t *testing.T) {
+	b, tg, _ := newTestBot(t, Config{})
+	tg.reject = func(c tgbotapi.Chattable) error {
+		if m, ok := c.(tgbotapi.MessageConfig); ok && m.ChatID == 1 && m.Text != messages["en"][msgSomethingWrong] {
+			panic("boom")
+		}
+		return nil
+	}
+	handled := make(chan struct{})
+	workers := newPool(1, func(u tgbotapi.Update) {
+		b.handleUpdate(u)
+		handled <- struct{}{}
+	})
+	defer workers.stop()
+	bad := command("new")
+	bad.Message.Chat.ID, bad.Message.From.ID = 1, 1
+	workers.dispatch(bad)
+	<-handled
+	workers.dispatch(command("new"))
+	<-handled
+
+	var good bool
+	for _, m := range tg.messages() {
+		good = good || m.ChatID == testUser && m.Text == messages["en"][msgChooseCategory]
+	}
+	if !good {
+		t.Errorf("the update after a panic was not handled; sent %q", tg.texts())
+	}
+}
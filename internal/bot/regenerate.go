@@ -0,0 +1,36 @@
+package bot
+
+import (
+	"math"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const regenerateCooldown = 10 * time.Second
+
+// handleRegenerate writes a fresh script for the user's current topic.
+func (b *Bot) handleRegenerate(userID int64) {
+	st := b.getState(userID)
+	now := time.Now()
+
+	b.mu.Lock()
+	topic := st.Topic
+	wait := regenerateCooldown - now.Sub(st.RegeneratedAt)
+	if topic != "" && wait <= 0 {
+		st.RegeneratedAt = now
+	}
+	b.mu.Unlock()
+
+	if topic == "" {
+		b.tg.Send(tgbotapi.NewMessage(userID, b.localized(userID, msgNothingToRegenerate)))
+		return
+	}
+	if wait > 0 {
+		secs := int(math.Ceil(wait.Seconds()))
+		b.tg.Send(tgbotapi.NewMessage(userID, b.localizedf(userID, msgRegenerateWait, secs)))
+		return
+	}
+
+	b.handleTopicSelection(userID, topic)
+}
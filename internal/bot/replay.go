@@ -0,0 +1,94 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const replayPrefix = "replay:"
+
+// recentEpisodes returns a user's history newest first, matching the
+// numbering shown by /history.
+func (b *Bot) recentEpisodes(userID int64) []Episode {
+	eps := b.history.List(userID)
+	for i, j := 0, len(eps)-1; i < j; i, j = i+1, j-1 {
+		eps[i], eps[j] = eps[j], eps[i]
+	}
+	return eps
+}
+
+func (b *Bot) sendHistory(userID int64) {
+	eps := b.recentEpisodes(userID)
+	if len(eps) == 0 {
+		b.tg.Send(tgbotapi.NewMessage(userID, b.localized(userID, msgNoPodcasts)))
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(b.localized(userID, msgHistory) + "\n")
+	for i, ep := range eps {
+		fmt.Fprintf(&sb, "%d. %s — %s (%s)\n", i+1, ep.Category, ep.Topic, ep.CreatedAt.Format("Jan 2, 15:04"))
+	}
+	sb.WriteString("\n" + b.localized(userID, msgHistoryHint))
+	b.tg.Send(tgbotapi.NewMessage(userID, sb.String()))
+}
+
+// episodeAt returns the n-th (1-based) most recent episode.
+func (b *Bot) episodeAt(userID int64, arg string) (Episode, bool) {
+	n, err := strconv.Atoi(strings.TrimSpace(arg))
+	eps := b.recentEpisodes(userID)
+	if err != nil || n < 1 || n > len(eps) {
+		return Episode{}, false
+	}
+	return eps[n-1], true
+}
+
+// handleReplay re-sends a past episode's script with a button for its audio.
+func (b *Bot) handleReplay(userID int64, arg string) {
+	ep, ok := b.episodeAt(userID, arg)
+	if !ok {
+		b.tg.Send(tgbotapi.NewMessage(userID, b.localized(userID, msgReplayUsage)))
+		return
+	}
+
+	b.sendScript(userID, ep.Script)
+	if ep.AudioFileID == "" {
+		return
+	}
+	msg := tgbotapi.NewMessage(userID, b.localized(userID, msgReplayAudio))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🔊 Send audio", replayPrefix+strconv.FormatInt(ep.ID, 10)),
+		),
+	)
+	b.tg.Send(msg)
+}
+
+// handleReplayAudio re-sends the audio of the episode with the given
+// history ID by its Telegram file ID, so no new speech is generated. The
+// button keeps working after newer podcasts shift the /history numbers
+// and, with a StateDir, across restarts.
+func (b *Bot) handleReplayAudio(userID int64, arg string) {
+	id, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		return
+	}
+	ep, ok := b.history.Get(userID, id)
+	if !ok || ep.AudioFileID == "" {
+		return
+	}
+
+	file := tgbotapi.FileID(ep.AudioFileID)
+	if ep.Voice {
+		voice := tgbotapi.NewVoice(userID, file)
+		voice.Caption = ep.Topic
+		b.tg.Send(voice)
+		return
+	}
+	audio := tgbotapi.NewAudio(userID, file)
+	audio.Caption = ep.Topic
+	b.tg.Send(audio)
+}
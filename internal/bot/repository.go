@@ -0,0 +1,235 @@
+package bot
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite" // registers the "sqlite" driver
+)
+
+// Repository persists generated scripts for analytics and reuse.
+type Repository interface {
+	SaveEpisode(ctx context.Context, userID int64, category, topic, script string) (id int64, err error)
+	// SetEpisodeScript replaces an episode's script, e.g. after the user
+	// edits it during review.
+	SetEpisodeScript(ctx context.Context, id int64, script string) error
+	// SetEpisodeAudio records the file holding an episode's audio.
+	SetEpisodeAudio(ctx context.Context, id int64, path string) error
+	// SetEpisodeImage records the file holding an episode's cover art.
+	SetEpisodeImage(ctx context.Context, id int64, path string) error
+	// SetEpisodeRating records a listener's rating: 1 for 👍, -1 for 👎.
+	SetEpisodeRating(ctx context.Context, id int64, rating int) error
+	// UserEpisodes returns all of a user's episodes, oldest first.
+	UserEpisodes(ctx context.Context, userID int64) ([]StoredEpisode, error)
+	// PruneEpisodes deletes episodes created before cutoff and all but the
+	// newest keep of each user. A zero cutoff or keep is ignored. drop is
+	// called for each episode first, and an episode it fails for is kept
+	// for a later prune. It returns how many episodes were deleted.
+	PruneEpisodes(ctx context.Context, cutoff time.Time, keep int, drop func(StoredEpisode) error) (int, error)
+}
+
+// StoredEpisode is an episode read back from a Repository.
+type StoredEpisode struct {
+	ID        int64
+	UserID    int64
+	Category  string
+	Topic     string
+	Script    string
+	AudioPath string
+	ImagePath string
+	CreatedAt time.Time
+}
+
+// nopRepository is used when no database is configured.
+type nopRepository struct{}
+
+func (nopRepository) SaveEpisode(context.Context, int64, string, string, string) (int64, error) {
+	return 0, nil
+}
+
+func (nopRepository) SetEpisodeScript(context.Context, int64, string) error {
+	return nil
+}
+
+func (nopRepository) SetEpisodeAudio(context.Context, int64, string) error {
+	return nil
+}
+
+func (nopRepository) SetEpisodeImage(context.Context, int64, string) error {
+	return nil
+}
+
+func (nopRepository) SetEpisodeRating(context.Context, int64, int) error {
+	return nil
+}
+
+func (nopRepository) UserEpisodes(context.Context, int64) ([]StoredEpisode, error) {
+	return nil, nil
+}
+
+func (nopRepository) PruneEpisodes(context.Context, time.Time, int, func(StoredEpisode) error) (int, error) {
+	return 0, nil
+}
+
+const episodesSchema = `
+CREATE TABLE IF NOT EXISTS episodes (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id    INTEGER NOT NULL,
+	category   TEXT    NOT NULL,
+	topic      TEXT    NOT NULL,
+	script     TEXT    NOT NULL,
+	audio_path TEXT    NOT NULL DEFAULT '',
+	image_path TEXT    NOT NULL DEFAULT '',
+	rating     INTEGER NOT NULL DEFAULT 0,
+	created_at TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS episodes_user_id ON episodes (user_id, created_at);
+`
+
+// SQLiteRepository stores episodes in a SQLite database.
+type SQLiteRepository struct {
+	db *sql.DB
+}
+
+// OpenSQLite opens the database at path, creating the schema if needed.
+func OpenSQLite(path string) (*SQLiteRepository, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SQLiteRepository{db: db}, nil
+}
+
+// migrate creates the schema and adds columns missing from databases
+// created by older versions.
+func migrate(db *sql.DB) error {
+	if _, err := db.Exec(episodesSchema); err != nil {
+		return err
+	}
+	columns := []struct{ name, def string }{
+		{"audio_path", "TEXT NOT NULL DEFAULT ''"},
+		{"image_path", "TEXT NOT NULL DEFAULT ''"},
+		{"rating", "INTEGER NOT NULL DEFAULT 0"},
+	}
+	for _, col := range columns {
+		var n int
+		err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('episodes') WHERE name = ?`, col.name).Scan(&n)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			continue
+		}
+		if _, err := db.Exec(`ALTER TABLE episodes ADD COLUMN ` + col.name + ` ` + col.def); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SaveEpisode inserts a script and returns its row ID.
+func (r *SQLiteRepository) SaveEpisode(ctx context.Context, userID int64, category, topic, script string) (int64, error) {
+	res, err := r.db.ExecContext(ctx,
+		`INSERT INTO episodes (user_id, category, topic, script, created_at) VALUES (?, ?, ?, ?, ?)`,
+		userID, category, topic, script, time.Now().UTC())
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// SetEpisodeScript replaces an episode's script.
+func (r *SQLiteRepository) SetEpisodeScript(ctx context.Context, id int64, script string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE episodes SET script = ? WHERE id = ?`, script, id)
+	return err
+}
+
+// SetEpisodeAudio records the file holding an episode's audio.
+func (r *SQLiteRepository) SetEpisodeAudio(ctx context.Context, id int64, path string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE episodes SET audio_path = ? WHERE id = ?`, path, id)
+	return err
+}
+
+// SetEpisodeImage records the file holding an episode's cover art.
+func (r *SQLiteRepository) SetEpisodeImage(ctx context.Context, id int64, path string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE episodes SET image_path = ? WHERE id = ?`, path, id)
+	return err
+}
+
+// SetEpisodeRating records a listener's rating: 1 for 👍, -1 for 👎.
+func (r *SQLiteRepository) SetEpisodeRating(ctx context.Context, id int64, rating int) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE episodes SET rating = ? WHERE id = ?`, rating, id)
+	return err
+}
+
+// UserEpisodes returns all of a user's episodes, oldest first.
+func (r *SQLiteRepository) UserEpisodes(ctx context.Context, userID int64) ([]StoredEpisode, error) {
+	return r.queryEpisodes(ctx, `WHERE user_id = ? ORDER BY id`, userID)
+}
+
+// PruneEpisodes deletes episodes past the age or per-user count limit,
+// after drop succeeds for each.
+func (r *SQLiteRepository) PruneEpisodes(ctx context.Context, cutoff time.Time, keep int, drop func(StoredEpisode) error) (int, error) {
+	var conds []string
+	var args []any
+	if !cutoff.IsZero() {
+		conds = append(conds, `created_at < ?`)
+		args = append(args, cutoff.UTC())
+	}
+	if keep > 0 {
+		conds = append(conds, `id IN (SELECT id FROM (
+			SELECT id, ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY id DESC) AS n FROM episodes
+		) WHERE n > ?)`)
+		args = append(args, keep)
+	}
+	if len(conds) == 0 {
+		return 0, nil
+	}
+	eps, err := r.queryEpisodes(ctx, `WHERE `+strings.Join(conds, ` OR `)+` ORDER BY id`, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, ep := range eps {
+		if drop != nil && drop(ep) != nil {
+			continue
+		}
+		if _, err := r.db.ExecContext(ctx, `DELETE FROM episodes WHERE id = ?`, ep.ID); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// queryEpisodes selects the episodes matching where.
+func (r *SQLiteRepository) queryEpisodes(ctx context.Context, where string, args ...any) ([]StoredEpisode, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, user_id, category, topic, script, audio_path, image_path, created_at FROM episodes `+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var eps []StoredEpisode
+	for rows.Next() {
+		var ep StoredEpisode
+		if err := rows.Scan(&ep.ID, &ep.UserID, &ep.Category, &ep.Topic, &ep.Script, &ep.AudioPath, &ep.ImagePath, &ep.CreatedAt); err != nil {
+			return nil, err
+		}
+		eps = append(eps, ep)
+	}
+	return eps, rows.Err()
+}
+
+// Close closes the database.
+func (r *SQLiteRepository) Close() error {
+	return r.db.Close()
+}
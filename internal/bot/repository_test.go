@@ -0,0 +1,161 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// openTestRepository opens a fresh SQLite repository that is closed when
+// the test ends.
+func openTestRepository(t *testing.T) *SQLiteRepository {
+	t.Helper()
+	repo, err := OpenSQLite(filepath.Join(t.TempDir(), "podcaster.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { repo.Close() })
+	return repo
+}
+
+// saveAt stores an episode for userID as if it were created at createdAt.
+func saveAt(t *testing.T, repo *SQLiteRepository, userID int64, topic string, createdAt time.Time) int64 {
+	t.Helper()
+	ctx := context.Background()
+	id, err := repo.SaveEpisode(ctx, userID, "Tech", topic, "script")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.db.ExecContext(ctx, `UPDATE episodes SET created_at = ? WHERE id = ?`, createdAt.UTC(), id); err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+// storedTopics returns the topics userID has left in repo, oldest first.
+func storedTopics(t *testing.T, repo *SQLiteRepository, userID int64) []string {
+	t.Helper()
+	eps, err := repo.UserEpisodes(context.Background(), userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ts []string
+	for _, ep := range eps {
+		ts = append(ts, ep.Topic)
+	}
+	return ts
+}
+
+func TestPruneEpisodesBoundaries(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	tests := []struct {
+		name   string
+		cutoff time.Time
+		keep   int
+		kept   []string
+		other  []string
+	}{
+		{"no limits", time.Time{}, 0, []string{"old", "day", "hour", "new"}, []string{"a", "b"}},
+		{"exactly max age is kept", now.Add(-day), 0, []string{"day", "hour", "new"}, []string{"b"}},
+		{"just over max age", now.Add(-day + time.Second), 0, []string{"hour", "new"}, []string{"b"}},
+		{"max count keeps the newest per user", time.Time{}, 2, []string{"hour", "new"}, []string{"a", "b"}},
+		{"max count equal to the size", time.Time{}, 4, []string{"old", "day", "hour", "new"}, []string{"a", "b"}},
+		{"max count one below the size", time.Time{}, 3, []string{"day", "hour", "new"}, []string{"a", "b"}},
+		{"both limits", now.Add(-2 * time.Hour), 3, []string{"hour", "new"}, []string{"b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := openTestRepository(t)
+			saveAt(t, repo, testUser, "old", now.Add(-30*day))
+			saveAt(t, repo, testUser, "day", now.Add(-day))
+			saveAt(t, repo, 7, "a", now.Add(-2*day))
+			saveAt(t, repo, testUser, "hour", now.Add(-time.Hour))
+			saveAt(t, repo, 7, "b", now)
+			saveAt(t, repo, testUser, "new", now)
+
+			var dropped []string
+			n, err := repo.PruneEpisodes(context.Background(), tt.cutoff, tt.keep, func(ep StoredEpisode) error {
+				dropped = append(dropped, ep.Topic)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != len(dropped) {
+				t.Errorf("PruneEpisodes = %d, dropped %q", n, dropped)
+			}
+			if got := storedTopics(t, repo, testUser); !reflect.DeepEqual(got, tt.kept) {
+				t.Errorf("user kept %q, want %q", got, tt.kept)
+			}
+			if got := storedTopics(t, repo, 7); !reflect.DeepEqual(got, tt.other) {
+				t.Errorf("other user kept %q, want %q", got, tt.other)
+			}
+		})
+	}
+}
+
+func TestPruneEpisodesKeepsFailedDrops(t *testing.T) {
+	repo := openTestRepository(t)
+	now := time.Now()
+	saveAt(t, repo, testUser, "stuck", now.Add(-2*time.Hour))
+	saveAt(t, repo, testUser, "gone", now.Add(-2*time.Hour))
+
+	n, err := repo.PruneEpisodes(context.Background(), now.Add(-time.Hour), 0, func(ep StoredEpisode) error {
+		if ep.Topic == "stuck" {
+			return errors.New("disk full")
+		}
+		return nil
+	})
+	if err != nil || n != 1 {
+		t.Fatalf("PruneEpisodes = %d, %v; want 1", n, err)
+	}
+	if got := storedTopics(t, repo, testUser); !reflect.DeepEqual(got, []string{"stuck"}) {
+		t.Errorf("kept %q, want the episode whose drop failed", got)
+	}
+}
+
+func TestPrunerRemovesStoredEpisodes(t *testing.T) {
+	dir, exportDir := t.TempDir(), t.TempDir()
+	b, _, _ := newTestBot(t, Config{
+		DatabasePath:       filepath.Join(t.TempDir(), "podcaster.db"),
+		AudioDir:           dir,
+		HistoryExportDir:   exportDir,
+		HistoryMaxEpisodes: 1,
+	})
+	repo := b.repo.(*SQLiteRepository)
+	ctx := context.Background()
+	now := time.Now()
+	old := saveAt(t, repo, testUser, "old", now.Add(-time.Hour))
+	saveAt(t, repo, testUser, "new", now)
+	for _, name := range []string{"1.mp3", "1.png"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := repo.SetEpisodeAudio(ctx, old, "1.mp3"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.SetEpisodeImage(ctx, old, "1.png"); err != nil {
+		t.Fatal(err)
+	}
+
+	b.pruneStored(ctx, now)
+
+	if got := storedTopics(t, repo, testUser); !reflect.DeepEqual(got, []string{"new"}) {
+		t.Errorf("kept %q, want only the newest", got)
+	}
+	for _, name := range []string{"1.mp3", "1.png"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("%s still in AudioDir: %v", name, err)
+		}
+	}
+	exported, err := filepath.Glob(filepath.Join(exportDir, "*.json"))
+	if err != nil || len(exported) != 1 {
+		t.Errorf("exported %q, %v; want the pruned episode", exported, err)
+	}
+}
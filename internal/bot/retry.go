@@ -0,0 +1,80 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+const maxRetries = 3
+
+// retryBackoff is the wait before the first retry. It is a variable so
+// tests can shorten it.
+var retryBackoff = 500 * time.Millisecond
+
+// withRetry runs call, retrying rate-limit and server errors with
+// exponential backoff. It gives up early rather than sleep past ctx's
+// deadline.
+func withRetry(ctx context.Context, call func() error) error {
+	backoff := retryBackoff
+	for attempt := 0; ; attempt++ {
+		err := call()
+		if err == nil || attempt == maxRetries || !isRetryable(err) {
+			return err
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < backoff {
+			return err
+		}
+
+		t := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+		backoff *= 2
+	}
+}
+
+// isRetryable reports whether err is an OpenAI 429 or 5xx response. Quota
+// errors are also 429s but cannot succeed on retry.
+func isRetryable(err error) bool {
+	if isQuotaError(err) {
+		return false
+	}
+	var status int
+	var apiErr *openai.APIError
+	var reqErr *openai.RequestError
+	switch {
+	case errors.As(err, &apiErr):
+		status = apiErr.HTTPStatusCode
+	case errors.As(err, &reqErr):
+		status = reqErr.HTTPStatusCode
+	default:
+		return false
+	}
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// quotaCodes are OpenAI error codes for an account that is out of credits
+// or over its spending limit.
+var quotaCodes = []string{"insufficient_quota", "billing_hard_limit_reached", "billing_not_active"}
+
+// isQuotaError reports whether err means the OpenAI account cannot be
+// billed, so no request will succeed until the operator steps in.
+func isQuotaError(err error) bool {
+	var apiErr *openai.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	for _, code := range quotaCodes {
+		if apiErr.Code == code || apiErr.Type == code {
+			return true
+		}
+	}
+	return false
+}
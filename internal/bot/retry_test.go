@@ -0,0 +1,92 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func fastRetries(t *testing.T) {
+	t.Helper()
+	old := retryBackoff
+	retryBackoff = time.Millisecond
+	t.Cleanup(func() { retryBackoff = old })
+}
+
+// failing returns a call that fails with err n times, then succeeds, and
+// counts its calls.
+func failing(n int, err error) (call func() error, calls *int) {
+	calls = new(int)
+	return func() error {
+		*calls++
+		if *calls <= n {
+			return err
+		}
+		return nil
+	}, calls
+}
+
+func TestWithRetry(t *testing.T) {
+	fastRetries(t)
+	tests := []struct {
+		name      string
+		failures  int
+		err       error
+		wantCalls int
+		wantErr   bool
+	}{
+		{"server error twice", 2, &openai.APIError{HTTPStatusCode: http.StatusServiceUnavailable}, 3, false},
+		{"rate limited twice", 2, &openai.RequestError{HTTPStatusCode: http.StatusTooManyRequests}, 3, false},
+		{"server error forever", 10, &openai.APIError{HTTPStatusCode: http.StatusInternalServerError}, maxRetries + 1, true},
+		{"invalid request", 10, &openai.APIError{HTTPStatusCode: http.StatusBadRequest}, 1, true},
+		{"quota", 10, &openai.APIError{HTTPStatusCode: http.StatusTooManyRequests, Code: "insufficient_quota"}, 1, true},
+		{"other error", 10, errors.New("connection reset"), 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			call, calls := failing(tt.failures, tt.err)
+			err := withRetry(context.Background(), call)
+			if *calls != tt.wantCalls {
+				t.Errorf("made %d calls, want %d", *calls, tt.wantCalls)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWithRetryStopsAtTheDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	call, calls := failing(10, &openai.APIError{HTTPStatusCode: http.StatusBadGateway})
+
+	start := time.Now()
+	if err := withRetry(ctx, call); err == nil {
+		t.Error("withRetry succeeded")
+	}
+	if *calls != 1 || time.Since(start) >= retryBackoff {
+		t.Errorf("made %d calls in %v, want to give up instead of sleeping past the deadline", *calls, time.Since(start))
+	}
+}
+
+func TestScriptSurvivesTransientFailures(t *testing.T) {
+	fastRetries(t)
+	b, _, ai := newTestBot(t, Config{})
+	calls := 0
+	ai.chat = func(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+		calls++
+		if calls <= 2 {
+			return openai.ChatCompletionResponse{}, &openai.APIError{HTTPStatusCode: http.StatusServiceUnavailable}
+		}
+		return ai.MockAI.CreateChatCompletion(ctx, req)
+	}
+	b.handleTopicSelection(testUser, "Lighthouses")
+	if st := b.stateOf(testUser); st.ScriptText == "" {
+		t.Errorf("no script after %d calls", calls)
+	}
+}
@@ -0,0 +1,121 @@
+package bot
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const reviewPrefix = "review:"
+
+// reviewData is the callback data of a review button. It carries the
+// review's ScriptGen, so taps under an older version of the script are
+// ignored.
+func reviewData(gen int, action string) string {
+	return reviewPrefix + strconv.Itoa(gen) + ":" + action
+}
+
+// parseReviewData is the inverse of reviewData.
+func parseReviewData(data string) (gen int, action string, ok bool) {
+	rest, ok := strings.CutPrefix(data, reviewPrefix)
+	if !ok {
+		return 0, "", false
+	}
+	genText, action, _ := strings.Cut(rest, ":")
+	gen, err := strconv.Atoi(genText)
+	return gen, action, err == nil && action != ""
+}
+
+// sendReview shows the generated script with buttons to approve it for
+// narration, regenerate it or replace it with an edited version.
+func (b *Bot) sendReview(userID int64) {
+	st := b.getState(userID)
+	b.mu.Lock()
+	st.WaitingFor = StateReviewScript
+	st.ScriptGen++
+	script, gen := st.displayScript(), st.ScriptGen
+	b.mu.Unlock()
+
+	b.sendScript(userID, script)
+
+	msg := tgbotapi.NewMessage(userID, b.localized(userID, msgReview))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("✅ Approve", reviewData(gen, "approve")),
+		tgbotapi.NewInlineKeyboardButtonData("🔄 Regenerate", reviewData(gen, "regenerate")),
+		tgbotapi.NewInlineKeyboardButtonData("✏️ Edit", reviewData(gen, "edit")),
+	))
+	b.tg.Send(msg)
+}
+
+// handleReviewCallback handles the buttons under a script awaiting review.
+// Taps on an older review are ignored once the script was approved or the
+// flow moved on.
+func (b *Bot) handleReviewCallback(userID int64, action string) {
+	st := b.getState(userID)
+	b.mu.Lock()
+	reviewing := st.WaitingFor == StateReviewScript || st.WaitingFor == StateEditScript
+	script := st.ScriptText
+	if reviewing {
+		switch action {
+		case "approve":
+			st.WaitingFor = StateInitial
+		case "edit":
+			st.WaitingFor = StateEditScript
+		}
+	}
+	b.mu.Unlock()
+
+	if !reviewing {
+		b.tg.Send(tgbotapi.NewMessage(userID, b.localized(userID, msgNotReviewing)))
+		return
+	}
+
+	switch action {
+	case "approve":
+		b.recordAudio(userID, script)
+	case "regenerate":
+		b.handleRegenerate(userID)
+	case "edit":
+		b.tg.Send(tgbotapi.NewMessage(userID, b.localized(userID, msgSendEdit)))
+	}
+}
+
+// handleScriptEdit replaces the script under review with text the user sent.
+func (b *Bot) handleScriptEdit(userID int64, text string) {
+	script := strings.TrimSpace(text)
+	if script == "" || strings.HasPrefix(script, "/") {
+		b.tg.Send(tgbotapi.NewMessage(userID, b.localized(userID, msgEditAsText)))
+		return
+	}
+
+	st := b.getState(userID)
+	b.mu.Lock()
+	st.ScriptText = script
+	st.Segments = nil
+	id := st.EpisodeID
+	b.mu.Unlock()
+
+	// /download and the feed read scripts from the repository, so keep it
+	// in step with the edit.
+	if id != 0 {
+		if err := b.repo.SetEpisodeScript(context.Background(), id, script); err != nil {
+			b.log.Error("save edited script", "user_id", userID, "episode_id", id, "err", err)
+		}
+	}
+	b.sendReview(userID)
+}
+
+// recordAudio turns an approved script into audio and sends it, unless it
+// must first wait for the user's answer to the consent disclaimer.
+func (b *Bot) recordAudio(userID int64, script string) {
+	if b.askConsentFirst(userID) {
+		return
+	}
+	ctx, done := b.startJob(userID, msgJobAudio)
+	defer done()
+	clearProgress := b.sendProgress(userID, b.localized(userID, msgRecording))
+	defer clearProgress()
+	b.generateAndSendAudio(ctx, userID, script)
+}
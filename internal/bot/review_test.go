@@ -0,0 +1,77 @@
+package bot
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// reviewTap taps the review button for action under the latest review.
+func (b *Bot) reviewTap(action string) tgbotapi.Update {
+	return tap(reviewData(b.stateOf(testUser).ScriptGen, action))
+}
+
+func TestEditedScriptIsStored(t *testing.T) {
+	b, _, _ := newTestBot(t, Config{DatabasePath: filepath.Join(t.TempDir(), "podcaster.db")})
+	defer b.repo.(*SQLiteRepository).Close()
+
+	b.handleTopicSelection(testUser, "Lighthouses")
+	b.handleUpdate(b.reviewTap("edit"))
+	b.handleUpdate(text("My own script about lighthouses."))
+
+	if got := b.stateOf(testUser).ScriptText; got != "My own script about lighthouses." {
+		t.Errorf("script under review = %q", got)
+	}
+	eps, err := b.repo.UserEpisodes(context.Background(), testUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(eps) != 1 || eps[0].Script != "My own script about lighthouses." {
+		t.Errorf("stored episodes = %+v, want the edited script", eps)
+	}
+}
+
+func TestStaleReviewTap(t *testing.T) {
+	b, tg, ai := newTestBot(t, Config{})
+	speech := recordSpeech(ai)
+	b.handleTopicSelection(testUser, "Lighthouses")
+	older := b.reviewTap("approve")
+	b.handleUpdate(b.reviewTap("edit"))
+	b.handleUpdate(text("My own script about lighthouses."))
+
+	// The first review's Approve would narrate a script the user has
+	// since replaced without seeing the new one.
+	b.handleUpdate(older)
+	if len(speech()) != 0 {
+		t.Error("a tap under an older review narrated the script")
+	}
+	if got := tg.callbackAnswers(); got[len(got)-1] != messages["en"][msgStaleButton] {
+		t.Errorf("answers = %q, want the stale button notice", got)
+	}
+	if got := b.stateOf(testUser).WaitingFor; got != StateReviewScript {
+		t.Errorf("waiting for %q, want the edited script still under review", got)
+	}
+
+	b.handleUpdate(b.reviewTap("approve"))
+	if len(speech()) == 0 {
+		t.Error("the latest review's Approve did not narrate")
+	}
+}
+
+func TestReviewButtonsSurviveNew(t *testing.T) {
+	b, _, ai := newTestBot(t, Config{})
+	speech := recordSpeech(ai)
+	b.handleTopicSelection(testUser, "Lighthouses")
+	older := b.reviewTap("approve")
+	b.handleUpdate(command("new"))
+	b.handleTopicSelection(testUser, "Tides")
+	if older.CallbackQuery.Data == b.reviewTap("approve").CallbackQuery.Data {
+		t.Fatal("a review after /new reused an older review's buttons")
+	}
+	b.handleUpdate(older)
+	if len(speech()) != 0 {
+		t.Error("a review button from before /new narrated the new script")
+	}
+}
@@ -0,0 +1,98 @@
+package bot
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const segmentsPrompt = " Structure it as an intro, two or three main points and an outro." +
+	" Start each section with a header line of its own: INTRO, POINT 1, POINT 2, POINT 3 or OUTRO."
+
+// Segment is a labeled section of a structured script.
+type Segment struct {
+	Title string
+	Text  string
+}
+
+var segmentHeaderRe = regexp.MustCompile(`(?i)^[#*\s\[]*(intro|introduction|(?:main\s+)?point\s*\d|outro|conclusion)[\]*]*\s*(?:[:.\-–—][*\s]*(.*))?$`)
+
+// parseSegments splits a script into its sections. It returns nil unless
+// the script has an intro, at least one main point and an outro, so callers
+// can fall back to the unstructured text. Text before the first header,
+// usually a preamble from the model, is dropped.
+func parseSegments(script string) []Segment {
+	var (
+		segs  []Segment
+		lines []string
+	)
+	flush := func() {
+		if len(segs) > 0 {
+			segs[len(segs)-1].Text = strings.TrimSpace(strings.Join(lines, "\n"))
+		}
+		lines = nil
+	}
+
+	intro, points, outro := false, 0, false
+	for _, line := range strings.Split(script, "\n") {
+		m := segmentHeaderRe.FindStringSubmatch(line)
+		if m == nil {
+			lines = append(lines, line)
+			continue
+		}
+		flush()
+
+		var title string
+		switch kind := strings.ToLower(m[1]); {
+		case kind == "intro" || kind == "introduction":
+			title, intro = "Intro", true
+		case kind == "outro" || kind == "conclusion":
+			title, outro = "Outro", true
+		default:
+			points++
+			title = fmt.Sprintf("Point %d", points)
+		}
+		segs = append(segs, Segment{Title: title})
+		if rest := strings.TrimSpace(m[2]); rest != "" {
+			lines = append(lines, rest)
+		}
+	}
+	flush()
+
+	if !intro || points == 0 || !outro {
+		return nil
+	}
+	for _, s := range segs {
+		if s.Text == "" {
+			return nil
+		}
+	}
+	return segs
+}
+
+// joinSegments returns the narration text of segs, without headers.
+func joinSegments(segs []Segment) string {
+	texts := make([]string, len(segs))
+	for i, s := range segs {
+		texts[i] = s.Text
+	}
+	return strings.Join(texts, "\n\n")
+}
+
+// formatSegments renders segs with bold Markdown headers for /text.
+func formatSegments(segs []Segment) string {
+	parts := make([]string, len(segs))
+	for i, s := range segs {
+		parts[i] = "*" + s.Title + "*\n" + s.Text
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// displayScript returns the script as shown to the user: with section
+// headers when it was parsed into segments. The caller must hold b.mu.
+func (st *UserState) displayScript() string {
+	if len(st.Segments) > 0 {
+		return formatSegments(st.Segments)
+	}
+	return st.ScriptText
+}
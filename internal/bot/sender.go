@@ -0,0 +1,21 @@
+package bot
+
+import (
+	"net/http"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Sender is the part of the Telegram Bot API the bot uses.
+// *tgbotapi.BotAPI implements it.
+type Sender interface {
+	Send(c tgbotapi.Chattable) (tgbotapi.Message, error)
+	Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error)
+	// MakeRequest calls a Bot API method by name, for parameters the
+	// library's configs lack.
+	MakeRequest(endpoint string, params tgbotapi.Params) (*tgbotapi.APIResponse, error)
+	GetUpdatesChan(config tgbotapi.UpdateConfig) tgbotapi.UpdatesChannel
+	StopReceivingUpdates()
+	HandleUpdate(r *http.Request) (*tgbotapi.Update, error)
+	GetMe() (tgbotapi.User, error)
+}
@@ -0,0 +1,72 @@
+package bot
+
+import (
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+const settingsPrefix = "settings:"
+
+// sendSettings summarizes every preference that survives /new, with a
+// button to change each. Show fields reuse the /myshow keyboard's actions.
+func (b *Bot) sendSettings(userID int64) {
+	st := b.getState(userID)
+	b.mu.Lock()
+	length := findLength(st.Length).Label
+	consent := st.Prefs.InstructionsConsent
+	b.mu.Unlock()
+
+	delivery := b.localized(userID, msgAudioFile)
+	if b.deliveryFormat(userID) == FormatVoice {
+		delivery = b.localized(userID, msgVoiceMessage)
+	}
+	quality := b.localized(userID, msgStandard)
+	if b.speechModel(userID) == openai.TTSModel1HD {
+		quality = "HD"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(b.describeShow(userID, msgSettingsHeader))
+	sb.WriteString("\n" + b.localizedf(userID, msgSettingsSummary, length, delivery, quality))
+	if b.cfg.RequireInstructionsConsent {
+		expressive := b.localized(userID, msgOff)
+		if consent {
+			expressive = b.localized(userID, msgOn)
+		}
+		sb.WriteString("\n" + b.localizedf(userID, msgExpressiveSetting, expressive))
+	}
+	sb.WriteString("\n\n" + b.localized(userID, msgSettingsHint))
+
+	buttons := []tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardButtonData("🎙 Voice", showPrefix+"voice"),
+		tgbotapi.NewInlineKeyboardButtonData("🎭 Style", showPrefix+"style"),
+		tgbotapi.NewInlineKeyboardButtonData("🌐 Language", showPrefix+"lang"),
+		tgbotapi.NewInlineKeyboardButtonData("⏩ Speed", showPrefix+"speed"),
+		tgbotapi.NewInlineKeyboardButtonData("⏱ Length", settingsPrefix+"length"),
+		tgbotapi.NewInlineKeyboardButtonData("📦 Delivery", settingsPrefix+"format"),
+		tgbotapi.NewInlineKeyboardButtonData("🎧 Quality", settingsPrefix+"quality"),
+	}
+	if b.cfg.RequireInstructionsConsent {
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData("✨ Expressive", settingsPrefix+"expressive"))
+	}
+
+	msg := tgbotapi.NewMessage(userID, sb.String())
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(keyboardRows(buttons, 2)...)
+	b.tg.Send(msg)
+}
+
+// handleSettingsCallback opens the selector for a /settings button.
+func (b *Bot) handleSettingsCallback(userID int64, setting string) {
+	switch setting {
+	case "length":
+		b.sendLengths(userID)
+	case "format":
+		b.sendFormats(userID)
+	case "quality":
+		b.sendQualities(userID)
+	case "expressive":
+		b.handleExpressiveCommand(userID)
+	}
+}
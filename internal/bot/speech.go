@@ -0,0 +1,248 @@
+package bot
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// speechLocale holds the spoken forms used when expanding text for TTS.
+type speechLocale struct {
+	abbreviations map[string]string
+	symbols       map[string]string
+	currencies    map[string]currency
+	percent       string
+	point         string
+	and           string
+	numberWords   func(n int64) string
+	yearWords     func(n int64) string
+}
+
+type currency struct {
+	one, many         string
+	minorOne, minorMn string
+}
+
+var speechLocales = map[string]*speechLocale{
+	"en": {
+		abbreviations: map[string]string{
+			"Dr.":     "Doctor",
+			"Mr.":     "Mister",
+			"Mrs.":    "Missus",
+			"Ms.":     "Miz",
+			"Prof.":   "Professor",
+			"Jr.":     "Junior",
+			"Sr.":     "Senior",
+			"vs.":     "versus",
+			"etc.":    "et cetera",
+			"e.g.":    "for example",
+			"i.e.":    "that is",
+			"approx.": "approximately",
+		},
+		symbols: map[string]string{
+			"&": "and",
+			"=": "equals",
+			"~": "about",
+		},
+		currencies: map[string]currency{
+			"$": {"dollar", "dollars", "cent", "cents"},
+			"€": {"euro", "euros", "cent", "cents"},
+			"£": {"pound", "pounds", "penny", "pence"},
+		},
+		percent:     "percent",
+		point:       "point",
+		and:         "and",
+		numberWords: englishNumber,
+		yearWords:   englishYear,
+	},
+}
+
+var (
+	numberRe = regexp.MustCompile(`([$€£])?(\d{1,3}(?:,\d{3})+|\d+)(\.\d+)?(\s?%)?`)
+	symbolRe = regexp.MustCompile(`\s([&=~])\s`)
+)
+
+// normalizeForSpeech expands abbreviations, numbers, currency amounts and
+// common symbols into the words a narrator would say in lang. Text in an
+// unsupported language is returned unchanged.
+func normalizeForSpeech(text, lang string) string {
+	loc, ok := speechLocales[lang]
+	if !ok {
+		return text
+	}
+
+	for abbr, full := range loc.abbreviations {
+		text = replaceWord(text, abbr, full)
+	}
+
+	text = symbolRe.ReplaceAllStringFunc(text, func(m string) string {
+		if w, ok := loc.symbols[strings.TrimSpace(m)]; ok {
+			return " " + w + " "
+		}
+		return m
+	})
+
+	var sb strings.Builder
+	last := 0
+	for _, idx := range numberRe.FindAllStringSubmatchIndex(text, -1) {
+		start, end := idx[0], idx[1]
+		if isWordRune(lastRune(text[:start])) || isWordRune(firstRune(text[end:])) {
+			continue
+		}
+		sb.WriteString(text[last:start])
+		sb.WriteString(loc.spell(submatch(text, idx, 1), submatch(text, idx, 2),
+			submatch(text, idx, 3), submatch(text, idx, 4) != ""))
+		last = end
+	}
+	sb.WriteString(text[last:])
+	return sb.String()
+}
+
+func (loc *speechLocale) spell(symbol, whole, frac string, percent bool) string {
+	grouped := strings.Contains(whole, ",")
+	n, err := strconv.ParseInt(strings.ReplaceAll(whole, ",", ""), 10, 64)
+	if err != nil {
+		return symbol + whole + frac
+	}
+
+	if cur, ok := loc.currencies[symbol]; ok {
+		out := loc.numberWords(n) + " " + plural(n, cur.one, cur.many)
+		if len(frac) == 3 {
+			minor, _ := strconv.ParseInt(frac[1:], 10, 64)
+			if minor > 0 {
+				out += " " + loc.and + " " + loc.numberWords(minor) + " " + plural(minor, cur.minorOne, cur.minorMn)
+			}
+		} else if frac != "" {
+			out = loc.decimal(n, frac) + " " + cur.many
+		}
+		return out
+	}
+
+	var out string
+	switch {
+	case frac != "":
+		out = loc.decimal(n, frac)
+	case !grouped && !percent && len(whole) == 4 && n >= 1100 && n < 2100:
+		out = loc.yearWords(n)
+	default:
+		out = loc.numberWords(n)
+	}
+	if percent {
+		out += " " + loc.percent
+	}
+	return out
+}
+
+func (loc *speechLocale) decimal(n int64, frac string) string {
+	digits := make([]string, 0, len(frac)-1)
+	for _, d := range frac[1:] {
+		digits = append(digits, loc.numberWords(int64(d-'0')))
+	}
+	return loc.numberWords(n) + " " + loc.point + " " + strings.Join(digits, " ")
+}
+
+func plural(n int64, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
+
+// replaceWord replaces abbr wherever it is not glued to a preceding letter.
+func replaceWord(text, abbr, full string) string {
+	var sb strings.Builder
+	for {
+		i := strings.Index(text, abbr)
+		if i < 0 {
+			sb.WriteString(text)
+			return sb.String()
+		}
+		sb.WriteString(text[:i])
+		if isWordRune(lastRune(text[:i])) {
+			sb.WriteString(abbr)
+		} else {
+			sb.WriteString(full)
+		}
+		text = text[i+len(abbr):]
+	}
+}
+
+func submatch(s string, idx []int, n int) string {
+	if idx[2*n] < 0 {
+		return ""
+	}
+	return s[idx[2*n]:idx[2*n+1]]
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func lastRune(s string) rune {
+	r, _ := utf8.DecodeLastRuneInString(s)
+	return r
+}
+
+func firstRune(s string) rune {
+	r, _ := utf8.DecodeRuneInString(s)
+	return r
+}
+
+var (
+	enOnes = []string{"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine",
+		"ten", "eleven", "twelve", "thirteen", "fourteen", "fifteen", "sixteen", "seventeen", "eighteen", "nineteen"}
+	enTens  = []string{"", "", "twenty", "thirty", "forty", "fifty", "sixty", "seventy", "eighty", "ninety"}
+	enScale = []struct {
+		value int64
+		name  string
+	}{
+		{1_000_000_000_000, "trillion"},
+		{1_000_000_000, "billion"},
+		{1_000_000, "million"},
+		{1_000, "thousand"},
+		{100, "hundred"},
+	}
+)
+
+func englishNumber(n int64) string {
+	if n < 0 {
+		return "minus " + englishNumber(-n)
+	}
+	if n < 20 {
+		return enOnes[n]
+	}
+	if n < 100 {
+		if n%10 == 0 {
+			return enTens[n/10]
+		}
+		return enTens[n/10] + "-" + enOnes[n%10]
+	}
+	for _, s := range enScale {
+		if n >= s.value {
+			out := englishNumber(n/s.value) + " " + s.name
+			if rest := n % s.value; rest > 0 {
+				out += " " + englishNumber(rest)
+			}
+			return out
+		}
+	}
+	return strconv.FormatInt(n, 10)
+}
+
+// englishYear reads a four-digit year the way it is usually spoken,
+// e.g. 1999 as "nineteen ninety-nine" and 2005 as "two thousand five".
+func englishYear(n int64) string {
+	hi, lo := n/100, n%100
+	switch {
+	case n >= 2000 && n < 2010:
+		return englishNumber(n)
+	case lo == 0:
+		return englishNumber(hi) + " hundred"
+	case lo < 10:
+		return englishNumber(hi) + " oh " + englishNumber(lo)
+	default:
+		return englishNumber(hi) + " " + englishNumber(lo)
+	}
+}
@@ -0,0 +1,32 @@
+package bot
+
+import "testing"
+
+func TestNormalizeForSpeech(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Dr. Smith vs. Mr. Jones", "Doctor Smith versus Mister Jones"},
+		{"Born in 1999.", "Born in nineteen ninety-nine."},
+		{"It costs $5.", "It costs five dollars."},
+		{"It costs $5.25 today", "It costs five dollars and twenty-five cents today"},
+		{"Pi is 3.14", "Pi is three point one four"},
+		{"Up 20% this year", "Up twenty percent this year"},
+		{"About 1,000,000 people", "About one million people"},
+		{"Salt & pepper", "Salt and pepper"},
+		{"MP3 and B2B stay", "MP3 and B2B stay"},
+		{"No numbers here.", "No numbers here."},
+	}
+	for _, tt := range tests {
+		if got := normalizeForSpeech(tt.in, "en"); got != tt.want {
+			t.Errorf("normalizeForSpeech(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeForSpeechUnknownLanguage(t *testing.T) {
+	const in = "Dr. Pérez pagó $5 en 1999."
+	if got := normalizeForSpeech(in, "xx"); got != in {
+		t.Errorf("normalizeForSpeech(%q, xx) = %q, want it unchanged", in, got)
+	}
+}
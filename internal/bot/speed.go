@@ -0,0 +1,67 @@
+package bot
+
+import (
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Speeds OpenAI's speech API accepts.
+const (
+	minSpeed = 0.25
+	maxSpeed = 4.0
+)
+
+const speedPrefix = "speed:"
+
+// speed returns the profile speed, falling back to 1.0.
+func (p ShowProfile) speed() float64 {
+	if p.Speed == 0 {
+		return 1
+	}
+	return p.Speed
+}
+
+// handleSpeedCommand sets the narration speed from args, e.g. "1.2" or
+// "1.2x", or offers common speeds when args is empty.
+func (b *Bot) handleSpeedCommand(userID int64, args string) {
+	args = strings.TrimSuffix(strings.TrimSpace(args), "x")
+	if args == "" {
+		b.sendSpeeds(userID)
+		return
+	}
+	b.setSpeed(userID, args)
+}
+
+func (b *Bot) sendSpeeds(userID int64) {
+	current := b.profile(userID).speed()
+
+	var buttons []tgbotapi.InlineKeyboardButton
+	for _, s := range showSpeeds {
+		v := strconv.FormatFloat(s, 'g', -1, 64)
+		label := v + "x"
+		if s == current {
+			label = "✅ " + label
+		}
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(label, speedPrefix+v))
+	}
+
+	msg := tgbotapi.NewMessage(userID, b.localizedf(userID, msgChooseSpeed, minSpeed, maxSpeed))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(keyboardRows(buttons, 4)...)
+	b.tg.Send(msg)
+}
+
+func (b *Bot) setSpeed(userID int64, value string) {
+	speed, err := strconv.ParseFloat(value, 64)
+	if err != nil || speed < minSpeed || speed > maxSpeed {
+		b.tg.Send(tgbotapi.NewMessage(userID, b.localizedf(userID, msgBadSpeed, minSpeed, maxSpeed)))
+		return
+	}
+
+	st := b.getState(userID)
+	b.mu.Lock()
+	st.Prefs.Show.Speed = speed
+	b.mu.Unlock()
+	b.tg.Send(tgbotapi.NewMessage(userID, b.localizedf(userID, msgSpeedSet, speed)))
+}
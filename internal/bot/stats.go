@@ -0,0 +1,116 @@
+package bot
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// wordsPerMinute is the narration pace used to estimate audio duration.
+const wordsPerMinute = 130
+
+// Stats counts a user's delivered podcasts. It lives in Prefs, so it is
+// kept across /new and persisted with the rest of the user's state.
+type Stats struct {
+	Podcasts     int
+	AudioSeconds int
+	// Tokens counts the chat completion tokens spent on the user.
+	Tokens int
+	// Categories counts podcasts per category.
+	Categories map[string]int
+}
+
+func (s Stats) clone() Stats {
+	cp := s
+	if s.Categories != nil {
+		cp.Categories = make(map[string]int, len(s.Categories))
+		for k, v := range s.Categories {
+			cp.Categories[k] = v
+		}
+	}
+	return cp
+}
+
+// favoriteCategory is the most chosen category, ties going to the
+// alphabetically first.
+func (s Stats) favoriteCategory() string {
+	var best string
+	for c, n := range s.Categories {
+		if m := s.Categories[best]; n > m || (n == m && c < best) {
+			best = c
+		}
+	}
+	return best
+}
+
+// estimateSeconds estimates how long narrating text takes at speed.
+func estimateSeconds(text string, speed float64) int {
+	if speed <= 0 {
+		speed = 1
+	}
+	words := len(strings.Fields(text))
+	return int(math.Round(float64(words) / wordsPerMinute * 60 / speed))
+}
+
+// recordStats counts a delivered podcast, preferring the duration Telegram
+// reports for the sent audio over an estimate from the narrated text.
+func (b *Bot) recordStats(userID int64, sent tgbotapi.Message, text string, speed float64) {
+	secs := 0
+	switch {
+	case sent.Audio != nil:
+		secs = sent.Audio.Duration
+	case sent.Voice != nil:
+		secs = sent.Voice.Duration
+	}
+	if secs <= 0 {
+		secs = estimateSeconds(text, speed)
+	}
+
+	st := b.getState(userID)
+	b.mu.Lock()
+	stats := &st.Prefs.Stats
+	stats.Podcasts++
+	stats.AudioSeconds += secs
+	if st.Category != "" {
+		if stats.Categories == nil {
+			stats.Categories = make(map[string]int)
+		}
+		stats.Categories[st.Category]++
+	}
+	b.mu.Unlock()
+}
+
+// recordTokens adds chat completion tokens to the user's stats.
+func (b *Bot) recordTokens(userID int64, tokens int) {
+	if tokens <= 0 {
+		return
+	}
+	st := b.getState(userID)
+	b.mu.Lock()
+	st.Prefs.Stats.Tokens += tokens
+	b.mu.Unlock()
+}
+
+// sendStats shows the user's usage totals.
+func (b *Bot) sendStats(userID int64) {
+	st := b.getState(userID)
+	b.mu.Lock()
+	stats := st.Prefs.Stats.clone()
+	b.mu.Unlock()
+
+	if stats.Podcasts == 0 {
+		b.tg.Send(tgbotapi.NewMessage(userID, b.localized(userID, msgNoPodcasts)))
+		return
+	}
+
+	text := b.localizedf(userID, msgStats, stats.Podcasts, time.Duration(stats.AudioSeconds)*time.Second)
+	if fav := stats.favoriteCategory(); fav != "" {
+		text += "\n" + b.localizedf(userID, msgStatsCategory, fav)
+	}
+	if stats.Tokens > 0 {
+		text += "\n" + b.localizedf(userID, msgStatsTokens, stats.Tokens)
+	}
+	b.tg.Send(tgbotapi.NewMessage(userID, text))
+}
@@ -0,0 +1,97 @@
+package bot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// StateStore persists user state between restarts.
+type StateStore interface {
+	// Load returns the saved state, or nil if the user has none.
+	Load(userID int64) (*UserState, error)
+	Save(userID int64, st *UserState) error
+	// Users lists the users with saved state.
+	Users() ([]int64, error)
+}
+
+// FileStore is a StateStore keeping one JSON file per user in a directory.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore creates a FileStore in dir, creating the directory if needed.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+func (s *FileStore) path(userID int64) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%d.json", userID))
+}
+
+// Load reads a user's state file.
+func (s *FileStore) Load(userID int64) (*UserState, error) {
+	data, err := os.ReadFile(s.path(userID))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var st UserState
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// Save atomically replaces a user's state file.
+func (s *FileStore) Save(userID int64, st *UserState) error {
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(s.dir, "state-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path(userID))
+}
+
+// Users lists the users with a state file in the directory.
+func (s *FileStore) Users() ([]int64, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var ids []int64
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok || e.IsDir() {
+			continue
+		}
+		if id, err := strconv.ParseInt(name, 10, 64); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
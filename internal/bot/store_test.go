@@ -0,0 +1,57 @@
+package bot
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestFileStore(t *testing.T) {
+	s, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st, err := s.Load(testUser); st != nil || err != nil {
+		t.Fatalf("Load of a new user = %+v, %v; want nil, nil", st, err)
+	}
+
+	want := &UserState{WaitingFor: StateTopic, Category: "Health", Topics: []string{"Sleep", "Diet"}, TopicsGen: 2}
+	if err := s.Save(testUser, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.Load(testUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Load = %+v, want %+v", got, want)
+	}
+
+	entries, _ := os.ReadDir(s.dir)
+	if len(entries) != 1 {
+		t.Errorf("store holds %d files, want one without leftover temp files", len(entries))
+	}
+	if ids, err := s.Users(); err != nil || !reflect.DeepEqual(ids, []int64{testUser}) {
+		t.Errorf("Users = %v, %v; want [%d]", ids, err, testUser)
+	}
+}
+
+func TestStateSurvivesRestarts(t *testing.T) {
+	dir := t.TempDir()
+	b, _, _ := newTestBot(t, Config{StateDir: dir})
+	b.handleUpdate(command("new"))
+	b.handleUpdate(tap(categoryPrefix + DefaultCategories[0]))
+	before := b.stateOf(testUser)
+
+	restarted, _, _ := newTestBot(t, Config{StateDir: dir})
+	after := restarted.stateOf(testUser)
+	if after.WaitingFor != StateTopic || after.Category != before.Category || !reflect.DeepEqual(after.Topics, before.Topics) {
+		t.Errorf("after a restart waiting for %q in %q with topics %q; want %+v", after.WaitingFor, after.Category, after.Topics, before)
+	}
+
+	// The restored topic buttons still work.
+	restarted.handleUpdate(tap(topicData(after.TopicsGen, 0)))
+	if got := restarted.stateOf(testUser).Topic; got != before.Topics[0] {
+		t.Errorf("topic = %q, want %q", got, before.Topics[0])
+	}
+}
@@ -0,0 +1,113 @@
+package bot
+
+import (
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// stylePreset shapes both the script's tone, sent as a system message, and,
+// for models that accept them, the narration instructions.
+type stylePreset struct {
+	Name         string
+	Prompt       string
+	Instructions string
+}
+
+var stylePresets = []stylePreset{
+	{
+		Name:         "Casual",
+		Prompt:       "Use a relaxed, conversational tone, like chatting with a friend.",
+		Instructions: "Speak in a relaxed, friendly and conversational way.",
+	},
+	{
+		Name:         "News",
+		Prompt:       "Use a crisp, factual news-bulletin tone.",
+		Instructions: "Speak like a clear, authoritative news anchor.",
+	},
+	{
+		Name:         "Storytelling",
+		Prompt:       "Tell it as a vivid story with a beginning, a twist and an ending.",
+		Instructions: "Speak like an engaging storyteller, building suspense and warmth.",
+	},
+	{
+		Name:         "Educational",
+		Prompt:       "Explain clearly for a curious learner, with simple examples.",
+		Instructions: "Speak like a patient, enthusiastic teacher.",
+	},
+}
+
+const scriptwriterPrompt = "You are a podcast scriptwriter."
+
+// styleSystemPrompt sets the tone of a user's scripts: their preset if one
+// is chosen, otherwise the operator's Config.ScriptStyle.
+func (b *Bot) styleSystemPrompt(userID int64) string {
+	tone := b.cfg.ScriptStyle
+	if s, ok := findStyle(b.profile(userID).Style); ok {
+		tone = s.Prompt
+	}
+	if tone == "" {
+		return ""
+	}
+	return scriptwriterPrompt + " " + tone
+}
+
+func findStyle(name string) (stylePreset, bool) {
+	for _, s := range stylePresets {
+		if strings.EqualFold(s.Name, name) {
+			return s, true
+		}
+	}
+	return stylePreset{}, false
+}
+
+const stylePrefix = "style:"
+
+// handleStyleCommand sets the user's style for the next generations, or
+// lists the presets when name is empty.
+func (b *Bot) handleStyleCommand(userID int64, name string) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		msg := tgbotapi.NewMessage(userID, b.localizedf(userID, msgStyles, styleNames()))
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(styleRow())
+		b.tg.Send(msg)
+		return
+	}
+	b.setStyle(userID, name)
+}
+
+func (b *Bot) setStyle(userID int64, name string) {
+	s, ok := findStyle(name)
+	if !ok && !strings.EqualFold(name, "none") {
+		b.tg.Send(tgbotapi.NewMessage(userID, b.localizedf(userID, msgUnknownStyle, styleNames())))
+		return
+	}
+
+	st := b.getState(userID)
+	b.mu.Lock()
+	st.Prefs.Show.Style = s.Name
+	b.mu.Unlock()
+
+	text := b.localized(userID, msgStyleCleared)
+	if ok {
+		text = b.localizedf(userID, msgStyleSet, s.Name)
+	}
+	b.tg.Send(tgbotapi.NewMessage(userID, text))
+}
+
+// styleRow is a keyboard row with one button per preset.
+func styleRow() []tgbotapi.InlineKeyboardButton {
+	var buttons []tgbotapi.InlineKeyboardButton
+	for _, s := range stylePresets {
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(s.Name, stylePrefix+s.Name))
+	}
+	return buttons
+}
+
+func styleNames() string {
+	names := make([]string, 0, len(stylePresets)+1)
+	for _, s := range stylePresets {
+		names = append(names, s.Name)
+	}
+	return strings.Join(append(names, "none"), ", ")
+}
@@ -0,0 +1,107 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const tempAudioPrefix = "podcast-"
+
+// orphanedTempAge is how old an untracked temp audio file must be before
+// startup cleanup treats it as left behind by a crashed run.
+const orphanedTempAge = time.Hour
+
+// createTempAudio creates a uniquely named, tracked temp file for a user's
+// audio, so concurrent generations never share a path. Callers remove it
+// with removeTempFile using the returned file's Name.
+func (b *Bot) createTempAudio(userID int64, ext string) (*os.File, error) {
+	f, err := os.CreateTemp(b.cfg.OutputDir, fmt.Sprintf(tempAudioPrefix+"%d-*%s", userID, ext))
+	if err != nil {
+		return nil, err
+	}
+	b.trackTempFile(f.Name())
+	return f, nil
+}
+
+// trackTempFile registers path for removal on shutdown.
+func (b *Bot) trackTempFile(path string) {
+	b.mu.Lock()
+	b.temp[path] = struct{}{}
+	b.mu.Unlock()
+}
+
+// removeTempFile deletes path and stops tracking it.
+func (b *Bot) removeTempFile(path string) {
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		b.log.Error("remove temp file", "path", path, "err", err)
+	}
+	b.mu.Lock()
+	delete(b.temp, path)
+	b.mu.Unlock()
+}
+
+// removeTempFiles deletes every temp file still tracked.
+func (b *Bot) removeTempFiles() {
+	b.mu.Lock()
+	paths := make([]string, 0, len(b.temp))
+	for p := range b.temp {
+		paths = append(paths, p)
+	}
+	b.mu.Unlock()
+
+	for _, p := range paths {
+		b.removeTempFile(p)
+	}
+}
+
+// removeOrphanedTempFiles deletes temp audio and archives left in
+// Config.OutputDir by a previous run that crashed mid-generation. Files this
+// run is using, and recent files that may belong to another instance, are
+// kept.
+func (b *Bot) removeOrphanedTempFiles(now time.Time) {
+	dir := b.cfg.OutputDir
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	var paths []string
+	for _, prefix := range []string{tempAudioPrefix, archivePrefix} {
+		matches, err := filepath.Glob(filepath.Join(dir, prefix+"*"))
+		if err != nil {
+			return
+		}
+		paths = append(paths, matches...)
+	}
+
+	for _, p := range paths {
+		b.mu.Lock()
+		_, inUse := b.temp[p]
+		b.mu.Unlock()
+		if inUse {
+			continue
+		}
+		fi, err := os.Stat(p)
+		if err != nil || !fi.Mode().IsRegular() || now.Sub(fi.ModTime()) < orphanedTempAge {
+			continue
+		}
+		if err := os.Remove(p); err != nil {
+			b.log.Error("remove orphaned temp file", "path", p, "err", err)
+			continue
+		}
+		b.log.Info("removed orphaned temp file", "path", p)
+	}
+}
+
+// checkWritable fails fast at startup if dir, e.g. on a read-only container
+// filesystem, cannot hold the bot's files.
+func checkWritable(dir string) error {
+	f, err := os.CreateTemp(dir, ".podcaster-write-check-*")
+	if err != nil {
+		return fmt.Errorf("bot: %s is not writable: %w", dir, err)
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
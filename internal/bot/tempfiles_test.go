@@ -0,0 +1,73 @@
+package bot
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestOverlappingSpeechGetsSeparateFiles(t *testing.T) {
+	b, _, ai := newTestBot(t, Config{})
+	started, release := blockingSpeech(ai)
+	enc := audioEncodings["mp3"]
+
+	files := make([]*os.File, 2)
+	var wg sync.WaitGroup
+	for i := range files {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			f, err := b.synthesize(context.Background(), testUser, openai.CreateSpeechRequest{Input: "Hello."}, enc)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			files[i] = f
+		}(i)
+	}
+	<-started
+	close(release)
+	wg.Wait()
+	if t.Failed() {
+		return
+	}
+	for _, f := range files {
+		f.Close()
+	}
+
+	a, c := files[0].Name(), files[1].Name()
+	if a == c {
+		t.Fatalf("both calls wrote %s", a)
+	}
+	for _, name := range []string{a, c} {
+		if !strings.HasPrefix(name, b.cfg.OutputDir) || !strings.HasSuffix(name, ".mp3") {
+			t.Errorf("temp file %s is not an .mp3 in %s", name, b.cfg.OutputDir)
+		}
+	}
+
+	// Removing one leaves the other in place.
+	b.removeTempFile(a)
+	if _, err := os.Stat(a); !os.IsNotExist(err) {
+		t.Errorf("%s still exists", a)
+	}
+	if info, err := os.Stat(c); err != nil || info.Size() == 0 {
+		t.Errorf("the other call's file is gone or empty: %v", err)
+	}
+}
+
+func TestTempFilesAreRemovedOnShutdown(t *testing.T) {
+	b, _, _ := newTestBot(t, Config{})
+	f, err := b.createTempAudio(testUser, ".mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	b.removeTempFiles()
+	if _, err := os.Stat(f.Name()); !os.IsNotExist(err) {
+		t.Errorf("%s survived shutdown", f.Name())
+	}
+}
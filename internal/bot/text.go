@@ -0,0 +1,154 @@
+package bot
+
+import (
+	"regexp"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// maxMessageLen is Telegram's message length limit, in UTF-16 code units.
+const maxMessageLen = 4096
+
+// partHeaderLen leaves room for a "Part 12/34" header above each chunk.
+const partHeaderLen = 16
+
+var (
+	sentenceEndRe = regexp.MustCompile(`[.!?…]+["'”’)\]]*\s+|\n`)
+	wordRe        = regexp.MustCompile(`^\s+|\S+\s*`)
+)
+
+// splitText splits s into chunks of at most limit UTF-16 code units, which
+// bounds both Telegram's message limit and OpenAI's character limits. It
+// packs whole paragraphs where possible, falls back to sentences for
+// overlong paragraphs, then to words, and only cuts inside a word, on a
+// rune boundary, when a single word exceeds the limit.
+func splitText(s string, limit int) []string {
+	var (
+		chunks []string
+		cur    strings.Builder
+		n      int
+	)
+	flush := func() {
+		if c := strings.TrimSpace(cur.String()); c != "" {
+			chunks = append(chunks, c)
+		}
+		cur.Reset()
+		n = 0
+	}
+
+	for _, para := range strings.SplitAfter(s, "\n\n") {
+		for _, seg := range splitLong(para, limit) {
+			w := utf16Len(seg)
+			if n+w > limit {
+				flush()
+			}
+			cur.WriteString(seg)
+			n += w
+		}
+	}
+	flush()
+	return chunks
+}
+
+// splitLong returns para whole if it fits in limit, and otherwise its
+// sentences, breaking any sentence that is still too long into words.
+func splitLong(para string, limit int) []string {
+	if utf16Len(para) <= limit {
+		return []string{para}
+	}
+	var out []string
+	start := 0
+	for _, idx := range sentenceEndRe.FindAllStringIndex(para, -1) {
+		out = append(out, splitWords(para[start:idx[1]], limit)...)
+		start = idx[1]
+	}
+	return append(out, splitWords(para[start:], limit)...)
+}
+
+// splitWords returns s whole if it fits in limit, and otherwise its words
+// with their trailing whitespace, hard-cutting any word that is still too
+// long.
+func splitWords(s string, limit int) []string {
+	if utf16Len(s) <= limit {
+		return []string{s}
+	}
+	var out []string
+	for _, word := range wordRe.FindAllString(s, -1) {
+		out = append(out, splitRunes(word, limit)...)
+	}
+	return out
+}
+
+// splitRunes cuts s into pieces of at most limit UTF-16 code units, never
+// splitting a rune.
+func splitRunes(s string, limit int) []string {
+	var out []string
+	start, n := 0, 0
+	for i, r := range s {
+		w := runeLen16(r)
+		if n+w > limit && n > 0 {
+			out = append(out, s[start:i])
+			start, n = i, 0
+		}
+		n += w
+	}
+	if start < len(s) {
+		out = append(out, s[start:])
+	}
+	return out
+}
+
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += runeLen16(r)
+	}
+	return n
+}
+
+func runeLen16(r rune) int {
+	if r > 0xFFFF {
+		return 2 // encoded as a surrogate pair
+	}
+	return 1
+}
+
+// markdownBalanced reports whether every legacy Markdown entity in s is
+// closed. Telegram rejects messages with an unclosed *, _, ` or [.
+func markdownBalanced(s string) bool {
+	var open byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case open == 0 && c == '\\':
+			i++ // escaped character
+		case open == 0 && len(s)-i >= 3 && s[i:i+3] == "```":
+			open = 'p'
+			i += 2
+		case open == 'p':
+			if len(s)-i >= 3 && s[i:i+3] == "```" {
+				open = 0
+				i += 2
+			}
+		case open == 0 && (c == '*' || c == '_' || c == '`' || c == '['):
+			open = c
+		case open == '[' && c == ']', open != '[' && c == open:
+			open = 0
+		}
+	}
+	return open == 0
+}
+
+// sendMarkdown sends text as Markdown, falling back to plain text when
+// the markup is unbalanced or Telegram refuses to parse it.
+func (b *Bot) sendMarkdown(userID int64, text string) {
+	if markdownBalanced(text) {
+		msg := tgbotapi.NewMessage(userID, text)
+		msg.ParseMode = tgbotapi.ModeMarkdown
+		if _, err := b.tg.Send(msg); err == nil {
+			return
+		}
+	}
+	b.tg.Send(tgbotapi.NewMessage(userID, text))
+}
@@ -0,0 +1,178 @@
+package bot
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// checkChunks fails t unless chunks are valid UTF-8, within limit and,
+// ignoring whitespace, add up to s.
+func checkChunks(t *testing.T, s string, limit int, chunks []string) {
+	t.Helper()
+	for i, c := range chunks {
+		if !utf8.ValidString(c) {
+			t.Errorf("chunk %d is not valid UTF-8: %q", i, c)
+		}
+		if n := utf16Len(c); n > limit {
+			t.Errorf("chunk %d is %d long, over %d", i, n, limit)
+		}
+		if c == "" {
+			t.Errorf("chunk %d is empty", i)
+		}
+	}
+	squash := func(s string) string { return strings.Join(strings.Fields(s), "") }
+	if got, want := squash(strings.Join(chunks, "")), squash(s); got != want {
+		t.Errorf("chunks lost text:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestSplitText(t *testing.T) {
+	tests := []struct {
+		name  string
+		s     string
+		limit int
+		want  []string
+	}{
+		{"fits", "One. Two.", 9, []string{"One. Two."}},
+		{"empty", "", 10, nil},
+		{"sentences", "One two. Three four. Five six.", 21, []string{"One two. Three four.", "Five six."}},
+		{"paragraphs first", "Para one.\n\nPara two.", 15, []string{"Para one.", "Para two."}},
+		{"words when a sentence is too long", "alpha beta gamma delta", 11, []string{"alpha beta", "gamma delta"}},
+		{"no whitespace", "abcdefghij", 4, []string{"abcd", "efgh", "ij"}},
+		{"multibyte", "ñandú ñandú", 5, []string{"ñandú", "ñandú"}},
+		{"surrogate pairs", "🎙🎙🎙", 4, []string{"🎙🎙", "🎙"}},
+		{"one over", "abcd efgh", 8, []string{"abcd", "efgh"}},
+		{"markdown", "*Bold intro.* Then _more_ text.", 14, []string{"*Bold intro.*", "Then _more_", "text."}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitText(tt.s, tt.limit)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitText(%q, %d) = %q, want %q", tt.s, tt.limit, got, tt.want)
+			}
+			checkChunks(t, tt.s, tt.limit, got)
+		})
+	}
+}
+
+func TestSplitTextLongScript(t *testing.T) {
+	var b strings.Builder
+	for i := 0; i < 400; i++ {
+		b.WriteString("Ça, c'est une phrase assez longue avec des accents — et un emoji 🎧. ")
+		if i%7 == 0 {
+			b.WriteString("\n\n")
+		}
+	}
+	b.WriteString(strings.Repeat("x", maxMessageLen+10))
+	s := b.String()
+	checkChunks(t, s, maxMessageLen, splitText(s, maxMessageLen))
+}
+
+// withScript gives the test user a finished script to review.
+func (b *Bot) withScript(script string) {
+	st := b.getState(testUser)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	st.WaitingFor = StateReviewScript
+	st.ScriptText = script
+}
+
+func TestTextSplitsLongScripts(t *testing.T) {
+	b, tg, _ := newTestBot(t, Config{})
+	var script strings.Builder
+	for script.Len() < 3*maxMessageLen {
+		script.WriteString("Про *жирный* текст и emoji 🎙 — достаточно длинное предложение. ")
+	}
+	b.withScript(script.String())
+	b.handleUpdate(command("text"))
+
+	msgs := tg.messages()
+	if len(msgs) < 2 {
+		t.Fatalf("sent %d messages, want the script in parts", len(msgs))
+	}
+	var parts []string
+	for i, m := range msgs {
+		if n := utf16Len(m.Text); n > maxMessageLen {
+			t.Errorf("message %d is %d long", i, n)
+		}
+		if !utf8.ValidString(m.Text) {
+			t.Errorf("message %d splits a rune", i)
+		}
+		if m.ParseMode != "" && !markdownBalanced(m.Text) {
+			t.Errorf("message %d has unbalanced markdown: %q", i, m.Text)
+		}
+		_, part, _ := strings.Cut(m.Text, "\n\n") // drop the part header
+		parts = append(parts, part)
+	}
+	checkChunks(t, script.String(), maxMessageLen, parts)
+}
+
+func TestMarkdownBalanced(t *testing.T) {
+	tests := []struct {
+		s    string
+		want bool
+	}{
+		{"plain", true},
+		{"*bold* and _italic_ and `code`", true},
+		{"[link](https://example.com)", true},
+		{"```\nsnake_case * 2\n```", true},
+		{`an escaped \* star`, true},
+		{"an open *bold", false},
+		{"snake_case", false},
+		{"[dangling", false},
+		{"```\nunclosed", false},
+	}
+	for _, tt := range tests {
+		if got := markdownBalanced(tt.s); got != tt.want {
+			t.Errorf("markdownBalanced(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestTextFallsBackToPlainText(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		reject bool
+	}{
+		{"unbalanced markup", "An *unclosed star and snake_case_names.", false},
+		{"markup Telegram refuses", "*Bold* but [an odd](entity", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, tg, _ := newTestBot(t, Config{})
+			if tt.reject {
+				tg.reject = func(c tgbotapi.Chattable) error {
+					if m, ok := c.(tgbotapi.MessageConfig); ok && m.ParseMode != "" {
+						return errors.New("Bad Request: can't parse entities")
+					}
+					return nil
+				}
+			}
+			b.withScript(tt.script)
+			b.handleUpdate(command("text"))
+
+			msgs := tg.messages()
+			if len(msgs) != 1 {
+				t.Fatalf("sent %d messages, want 1", len(msgs))
+			}
+			if msgs[0].Text != tt.script || msgs[0].ParseMode != "" {
+				t.Errorf("sent %q in mode %q, want the script as plain text", msgs[0].Text, msgs[0].ParseMode)
+			}
+		})
+	}
+}
+
+func TestTextKeepsValidMarkdown(t *testing.T) {
+	b, tg, _ := newTestBot(t, Config{})
+	b.withScript("A *bold* _claim_.")
+	b.handleUpdate(command("text"))
+	if msgs := tg.messages(); len(msgs) != 1 || msgs[0].ParseMode != tgbotapi.ModeMarkdown {
+		t.Errorf("sent %+v, want one Markdown message", msgs)
+	}
+}
@@ -0,0 +1,58 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// maxTitleLen caps episode titles, in runes, well under Telegram's
+// 1024-character caption limit.
+const maxTitleLen = 100
+
+const titlePromptPrefix = "Write a catchy title for this podcast episode"
+
+// titlePrompt asks for an episode title. Only the start of the script is
+// sent, which is enough to name it and keeps the call cheap.
+func titlePrompt(topic, script string) string {
+	if r := []rune(script); len(r) > 1500 {
+		script = string(r[:1500])
+	}
+	return fmt.Sprintf("%s about %s. Reply with the title only, at most 8 words, no quotes.\n\nScript:\n%s",
+		titlePromptPrefix, topic, script)
+}
+
+// cleanTitle reduces the model's reply to a single plain line that is safe
+// inside a Markdown caption.
+func cleanTitle(reply string) string {
+	title, _, _ := strings.Cut(strings.TrimSpace(reply), "\n")
+	title = strings.TrimSpace(title)
+	if t, ok := strings.CutPrefix(title, "Title:"); ok {
+		title = strings.TrimSpace(t)
+	}
+	title = strings.Map(func(r rune) rune {
+		if strings.ContainsRune("*_`[]", r) {
+			return -1
+		}
+		return r
+	}, title)
+	title = strings.Trim(title, ` "'“”«»`)
+	if r := []rune(title); len(r) > maxTitleLen {
+		title = strings.TrimSpace(string(r[:maxTitleLen])) + "…"
+	}
+	return title
+}
+
+// generateTitle names an episode, falling back to its topic if the model
+// fails.
+func (b *Bot) generateTitle(ctx context.Context, userID int64, topic, script string) string {
+	reply, err := b.chat(ctx, userID, "", titlePrompt(topic, script))
+	if err != nil {
+		b.log.Error("generate title", "user_id", userID, "err", err)
+		return cleanTitle(topic)
+	}
+	if title := cleanTitle(reply); title != "" {
+		return title
+	}
+	return cleanTitle(topic)
+}
@@ -0,0 +1,162 @@
+package bot
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+var (
+	listMarkerRe   = regexp.MustCompile(`^\s*(?:[-*+•]|\d+[.)]|\(\d+\))\s+`)
+	inlineNumberRe = regexp.MustCompile(`[,;]?\s+\d+[.)]\s+`)
+	emphasisRe     = regexp.MustCompile(`\*\*(.+?)\*\*|__(.+?)__`)
+)
+
+// parseTopics reads up to limit topics from the model's {"topics": [...]}
+// reply, falling back to splitTopics when it is not JSON, e.g. from a model
+// or gateway that ignores JSON mode. JSON of another shape has no topics;
+// splitting it would only make buttons out of braces and keys.
+func parseTopics(reply string, limit int) []string {
+	if !json.Valid([]byte(reply)) {
+		return splitTopics(reply, limit)
+	}
+	var parsed struct {
+		Topics []string `json:"topics"`
+	}
+	if err := json.Unmarshal([]byte(reply), &parsed); err != nil {
+		return nil
+	}
+	var topics []string
+	for _, t := range parsed.Topics {
+		topics = appendTopic(topics, t)
+	}
+	if len(topics) > limit {
+		topics = topics[:limit]
+	}
+	return topics
+}
+
+// splitTopics parses the model's topic list into at most limit clean
+// topics. It accepts comma-separated text, one topic per line, and
+// markdown lists (numbered or bulleted), including mixtures of these.
+// When the output contains a list, any surrounding prose is dropped and
+// commas inside list items are kept.
+func splitTopics(input string, limit int) []string {
+	lines := strings.Split(input, "\n")
+
+	hasList := false
+	for _, line := range lines {
+		if listMarkerRe.MatchString(line) {
+			hasList = true
+			break
+		}
+	}
+
+	var topics []string
+	for _, line := range lines {
+		if hasList {
+			if !listMarkerRe.MatchString(line) {
+				continue
+			}
+			// "1. Foo, 2. Bar" puts several numbered items on one line.
+			for _, item := range inlineNumberRe.Split(listMarkerRe.ReplaceAllString(line, ""), -1) {
+				topics = appendTopic(topics, item)
+			}
+			continue
+		}
+		for _, part := range strings.Split(line, ",") {
+			topics = appendTopic(topics, part)
+		}
+	}
+
+	if len(topics) > limit {
+		topics = topics[:limit]
+	}
+	return topics
+}
+
+func appendTopic(topics []string, raw string) []string {
+	t := emphasisRe.ReplaceAllString(raw, "$1$2")
+	t = strings.Trim(strings.TrimSpace(t), `"'*_`)
+	t = strings.TrimRight(t, ".")
+	if t == "" || strings.HasSuffix(t, ":") {
+		return topics
+	}
+	for _, seen := range topics {
+		if strings.EqualFold(seen, t) {
+			return topics
+		}
+	}
+	return append(topics, t)
+}
+
+const (
+	topicPrefix    = "topic:"
+	moreTopicsData = "topics:more"
+	backData       = "nav:back"
+)
+
+// topicData is the callback data of the button for topic i of list gen.
+func topicData(gen, i int) string {
+	return topicPrefix + strconv.Itoa(gen) + ":" + strconv.Itoa(i)
+}
+
+// parseTopicData is the inverse of topicData.
+func parseTopicData(data string) (gen, i int, ok bool) {
+	rest, ok := strings.CutPrefix(data, topicPrefix)
+	if !ok {
+		return 0, 0, false
+	}
+	genText, index, _ := strings.Cut(rest, ":")
+	gen, genErr := strconv.Atoi(genText)
+	i, err := strconv.Atoi(index)
+	return gen, i, genErr == nil && err == nil
+}
+
+// maxAvoidTopics caps how many earlier suggestions the topics prompt lists.
+const maxAvoidTopics = 20
+
+// recentTopics returns the latest maxAvoidTopics of topics.
+func recentTopics(topics []string) []string {
+	if len(topics) > maxAvoidTopics {
+		topics = topics[len(topics)-maxAvoidTopics:]
+	}
+	return append([]string(nil), topics...)
+}
+
+// handleMoreTopics suggests a fresh set of topics for the current category,
+// avoiding the ones already offered.
+func (b *Bot) handleMoreTopics(userID int64) {
+	st := b.getState(userID)
+	b.mu.Lock()
+	category := st.Category
+	b.mu.Unlock()
+
+	if category == "" {
+		b.tg.Send(tgbotapi.NewMessage(userID, b.localized(userID, msgNeedCategory)))
+		return
+	}
+	b.generateTopics(userID)
+}
+
+// handleBack takes a user choosing a topic back to category selection,
+// dropping the category's topics and angle. Other taps are ignored.
+func (b *Bot) handleBack(userID int64) {
+	st := b.getState(userID)
+	b.mu.Lock()
+	ok := st.WaitingFor == StateTopic
+	if ok {
+		st.Category = ""
+		st.Angle = ""
+		st.Topics = nil
+		st.SuggestedTopics = nil
+	}
+	b.mu.Unlock()
+
+	if ok {
+		b.sendCategories(userID)
+	}
+}
@@ -0,0 +1,314 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestSplitTopicsMixedFormats(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name:  "numbered markdown list",
+			input: "1. Black holes\n2. Dark matter\n3. Exoplanets",
+			want:  []string{"Black holes", "Dark matter", "Exoplanets"},
+		},
+		{
+			name:  "bold list items",
+			input: "1. **Black holes**\n2. __Dark matter__",
+			want:  []string{"Black holes", "Dark matter"},
+		},
+		{
+			name:  "commas on some lines, one topic on others",
+			input: "Black holes, Dark matter\nExoplanets\nComets",
+			want:  []string{"Black holes", "Dark matter", "Exoplanets", "Comets"},
+		},
+		{
+			name:  "numbered and bulleted items with prose around them",
+			input: "Here are some topics:\n1. Black holes\n- Dark matter\n* Exoplanets, and their moons\nEnjoy!",
+			want:  []string{"Black holes", "Dark matter", "Exoplanets, and their moons"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitTopics(tt.input, 10); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitTopics(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitTopics(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"comma list", "Foo, Bar , Baz", []string{"Foo", "Bar", "Baz"}},
+		{"numbered on one line", "1. Foo, 2. Bar, 3. Baz", []string{"Foo", "Bar", "Baz"}},
+		{"numbered with parens", "1) Foo\n2) Bar\n(3) Baz", []string{"Foo", "Bar", "Baz"}},
+		{"newline list", "Foo\n\nBar\n  Baz  \n", []string{"Foo", "Bar", "Baz"}},
+		{"bullets", "- Foo\n• Bar\n+ Baz", []string{"Foo", "Bar", "Baz"}},
+		{"quotes and trailing dots", `"Foo", 'Bar', Baz.`, []string{"Foo", "Bar", "Baz"}},
+		{"duplicates", "Foo, foo, Bar", []string{"Foo", "Bar"}},
+		{"empty entries", ", ,Foo,,", []string{"Foo"}},
+		{"capped", "A, B, C, D, E, F, G", []string{"A", "B", "C", "D", "E"}},
+		{"nothing", "  \n ", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitTopics(tt.input, 5); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitTopics(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTopics(t *testing.T) {
+	tests := []struct {
+		name  string
+		reply string
+		want  []string
+	}{
+		{"json", `{"topics": ["Black holes", "Dark matter"]}`, []string{"Black holes", "Dark matter"}},
+		{"json is cleaned and capped", `{"topics": ["**A**", "a", "B", "C", "D"]}`, []string{"A", "B", "C"}},
+		{"plain text", "1. Black holes\n2. Dark matter", []string{"Black holes", "Dark matter"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseTopics(tt.reply, 3); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseTopics(%q) = %q, want %q", tt.reply, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTopicsFallsBackOnBadJSON(t *testing.T) {
+	reply := `{"topics": ["Black holes", "Dark matter"`
+	if got, want := parseTopics(reply, 3), splitTopics(reply, 3); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseTopics(%q) = %q, want the text parser's %q", reply, got, want)
+	}
+}
+
+func TestParseTopicsOtherJSON(t *testing.T) {
+	for _, reply := range []string{
+		`{"ideas": ["Black holes"]}`,
+		`{"topics": []}`,
+		`{"topics": "Black holes"}`,
+		`["Black holes"]`,
+	} {
+		if got := parseTopics(reply, 3); len(got) != 0 {
+			t.Errorf("parseTopics(%q) = %q, want no topics", reply, got)
+		}
+	}
+}
+
+func TestTopicsAskForJSON(t *testing.T) {
+	b, _, ai := newTestBot(t, Config{})
+	var format *openai.ChatCompletionResponseFormat
+	ai.chat = func(_ context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+		format = req.ResponseFormat
+		return reply("Black holes, Dark matter"), nil
+	}
+	b.handleUpdate(command("new"))
+	b.handleUpdate(tap(categoryPrefix + DefaultCategories[0]))
+
+	if format == nil || format.Type != openai.ChatCompletionResponseFormatTypeJSONObject {
+		t.Errorf("topics requested with format %+v, want a JSON object", format)
+	}
+	// A reply that ignores JSON mode still gives topics.
+	if got := b.stateOf(testUser).Topics; !reflect.DeepEqual(got, []string{"Black holes", "Dark matter"}) {
+		t.Errorf("topics = %q", got)
+	}
+}
+
+// topicButtons returns the labels of markup's topic buttons.
+func topicButtons(markup tgbotapi.InlineKeyboardMarkup) []string {
+	var labels []string
+	for _, row := range markup.InlineKeyboard {
+		for _, button := range row {
+			if button.CallbackData != nil && strings.HasPrefix(*button.CallbackData, topicPrefix) {
+				labels = append(labels, button.Text)
+			}
+		}
+	}
+	return labels
+}
+
+func TestNumTopics(t *testing.T) {
+	var ten []string
+	for i := 1; i <= 10; i++ {
+		ten = append(ten, "Topic "+strconv.Itoa(i))
+	}
+	data, _ := json.Marshal(map[string][]string{"topics": ten})
+
+	for _, n := range []int{3, 8} {
+		t.Run(strconv.Itoa(n), func(t *testing.T) {
+			b, tg, ai := newTestBot(t, Config{NumTopics: n})
+			ai.chat = func(context.Context, openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+				return reply(string(data)), nil
+			}
+			b.handleUpdate(command("new"))
+			b.handleUpdate(tap(categoryPrefix + DefaultCategories[0]))
+
+			if want := fmt.Sprintf("Generate %d podcast topics", n); !ai.prompted(want) {
+				t.Errorf("prompts %q do not ask for %d topics", ai.prompts, n)
+			}
+			if got := b.stateOf(testUser).Topics; !reflect.DeepEqual(got, ten[:n]) {
+				t.Errorf("topics = %q, want %q", got, ten[:n])
+			}
+			msgs := tg.messages()
+			markup, _ := msgs[len(msgs)-1].ReplyMarkup.(tgbotapi.InlineKeyboardMarkup)
+			if got := topicButtons(markup); !reflect.DeepEqual(got, ten[:n]) {
+				t.Errorf("buttons = %q, want %q", got, ten[:n])
+			}
+		})
+	}
+}
+
+func TestNumTopicsIsBounded(t *testing.T) {
+	for _, n := range []int{-1, MaxNumTopics + 1} {
+		if _, err := New(&fakeSender{}, &fakeAI{}, Config{NumTopics: n, OutputDir: t.TempDir()}); err == nil {
+			t.Errorf("New accepted %d topics", n)
+		}
+	}
+}
+
+func TestTopicKeyboardRows(t *testing.T) {
+	tests := []struct {
+		topics int
+		rows   []int
+	}{
+		{0, nil},
+		{1, []int{1}},
+		{3, []int{3}},
+		{7, []int{3, 3, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.topics), func(t *testing.T) {
+			topics := make([]string, tt.topics)
+			for i := range topics {
+				topics[i] = "Topic " + strconv.Itoa(i)
+			}
+			var rows []int
+			for _, row := range topicKeyboard(topics, 0, 0).InlineKeyboard {
+				rows = append(rows, len(row))
+			}
+			if !reflect.DeepEqual(rows, tt.rows) {
+				t.Errorf("rows = %v, want %v", rows, tt.rows)
+			}
+		})
+	}
+}
+
+func TestNoTopicsAsksToRetry(t *testing.T) {
+	b, tg, ai := newTestBot(t, Config{})
+	ai.chat = func(context.Context, openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+		return reply(`{"topics": []}`), nil
+	}
+	b.handleUpdate(command("new"))
+	b.handleUpdate(tap(categoryPrefix + DefaultCategories[0]))
+
+	msgs := tg.messages()
+	last := msgs[len(msgs)-1]
+	if last.Text != messages["en"][msgError] {
+		t.Fatalf("last message = %q, want the error", last.Text)
+	}
+	markup, _ := last.ReplyMarkup.(tgbotapi.InlineKeyboardMarkup)
+	if len(markup.InlineKeyboard) != 1 || *markup.InlineKeyboard[0][0].CallbackData != retryPrefix+stepTopics {
+		t.Errorf("error keyboard = %+v, want a retry button", markup.InlineKeyboard)
+	}
+}
+
+// longTopic is well past Telegram's 64-byte callback data limit.
+const longTopic = "How the quiet, patient work of generations of lighthouse keepers kept whole coastlines safe"
+
+// withTopics makes the model suggest topics.
+func withTopics(ai *fakeAI, topics ...string) {
+	data, _ := json.Marshal(map[string][]string{"topics": topics})
+	ai.chat = func(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+		if strings.Contains(req.Messages[len(req.Messages)-1].Content, " podcast topics about ") {
+			return reply(string(data)), nil
+		}
+		return ai.MockAI.CreateChatCompletion(ctx, req)
+	}
+}
+
+func TestLongTopicButtons(t *testing.T) {
+	b, tg, ai := newTestBot(t, Config{})
+	withTopics(ai, "Short", longTopic)
+	b.handleUpdate(command("new"))
+	b.handleUpdate(tap(categoryPrefix + DefaultCategories[0]))
+
+	msgs := tg.messages()
+	markup, _ := msgs[len(msgs)-1].ReplyMarkup.(tgbotapi.InlineKeyboardMarkup)
+	var data string
+	for _, row := range markup.InlineKeyboard {
+		for _, button := range row {
+			if len(*button.CallbackData) > 64 {
+				t.Errorf("button %q has %d bytes of data", button.Text, len(*button.CallbackData))
+			}
+			if button.Text == longTopic {
+				data = *button.CallbackData
+			}
+		}
+	}
+	if data == "" {
+		t.Fatalf("no button is labelled with the full topic")
+	}
+
+	b.handleUpdate(tap(data))
+	if got := b.stateOf(testUser).Topic; got != longTopic {
+		t.Errorf("topic = %q, want %q", got, longTopic)
+	}
+}
+
+func TestOlderTopicButtonsAreRejected(t *testing.T) {
+	b, tg, ai := newTestBot(t, Config{})
+	withTopics(ai, "First")
+	b.handleUpdate(command("new"))
+	b.handleUpdate(tap(categoryPrefix + DefaultCategories[0]))
+	old := topicData(b.stateOf(testUser).TopicsGen, 0)
+
+	// More topics replaces the list; the first list's buttons now point at
+	// different topics.
+	withTopics(ai, "Second")
+	b.handleUpdate(tap(moreTopicsData))
+	if got := b.stateOf(testUser).Topics; !reflect.DeepEqual(got, []string{"Second"}) {
+		t.Fatalf("topics = %q after More topics", got)
+	}
+
+	b.handleUpdate(tap(old))
+	if st := b.stateOf(testUser); st.Topic != "" {
+		t.Errorf("a button of the older list chose %q", st.Topic)
+	}
+	if got := tg.callbackAnswers(); len(got) == 0 || got[len(got)-1] != messages["en"][msgStaleButton] {
+		t.Errorf("answers = %q, want the stale button notice", got)
+	}
+}
+
+func TestScriptPromptHasTheFullTopic(t *testing.T) {
+	b, _, ai := newTestBot(t, Config{})
+	withTopics(ai, longTopic)
+	b.handleUpdate(command("new"))
+	b.handleUpdate(tap(categoryPrefix + DefaultCategories[0]))
+	b.handleUpdate(tap(topicData(b.stateOf(testUser).TopicsGen, 0)))
+
+	if !ai.prompted(longTopic) {
+		t.Errorf("no prompt has the full topic %q", longTopic)
+	}
+	if got := b.stateOf(testUser).Topic; got != longTopic {
+		t.Errorf("topic = %q, want %q", got, longTopic)
+	}
+}
@@ -0,0 +1,137 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// maxSpeechInput is OpenAI's TTS input limit, in characters.
+const maxSpeechInput = 4096
+
+// speechPart is text, at most maxSpeechInput long, for one voice.
+type speechPart struct {
+	Voice openai.SpeechVoice
+	Text  string
+}
+
+// speechParts splits text for voice into parts that fit the input limit.
+func speechParts(text string, voice openai.SpeechVoice) []speechPart {
+	var parts []speechPart
+	for _, chunk := range splitText(text, maxSpeechInput) {
+		parts = append(parts, speechPart{Voice: voice, Text: chunk})
+	}
+	return parts
+}
+
+// speak synthesizes each part with req's settings in order and joins the
+// audio into one tracked temp file, which the caller removes with
+// removeTempFile.
+func (b *Bot) speak(ctx context.Context, userID int64, req openai.CreateSpeechRequest, parts []speechPart, enc audioEncoding) (*os.File, error) {
+	var files []*os.File
+	defer func() {
+		for _, f := range files {
+			f.Close()
+			b.removeTempFile(f.Name())
+		}
+	}()
+
+	start := time.Now()
+	for _, p := range parts {
+		req.Input, req.Voice = p.Text, p.Voice
+		f, err := b.synthesize(ctx, userID, req, enc)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	b.metrics.observeTTS(time.Since(start))
+
+	if len(files) == 1 {
+		f := files[0]
+		files = nil
+		return f, nil
+	}
+	return b.joinAudio(ctx, userID, files, enc)
+}
+
+// synthesize makes one CreateSpeech call and saves the audio to a tracked
+// temp file.
+func (b *Bot) synthesize(ctx context.Context, userID int64, req openai.CreateSpeechRequest, enc audioEncoding) (*os.File, error) {
+	release, err := b.acquireAI(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	// The timeout also covers downloading the audio body.
+	speechCtx, cancel := context.WithTimeout(ctx, b.cfg.AITimeout)
+	defer cancel()
+
+	start := time.Now()
+	var resp openai.RawResponse
+	err = withRetry(speechCtx, func() (err error) {
+		resp, err = b.ai.CreateSpeech(speechCtx, req)
+		return err
+	})
+	if err != nil {
+		b.metrics.aiError(err)
+		b.log.Error("speech", "user_id", userID, "model", req.Model, "latency", time.Since(start), "err", err)
+		return nil, err
+	}
+	defer resp.Close()
+	chars := utf8.RuneCountInString(req.Input)
+	b.spendAI(0, chars)
+	b.log.Info("speech", "user_id", userID, "model", req.Model, "voice", req.Voice,
+		"chars", chars, "latency", time.Since(start))
+
+	f, err := b.createTempAudio(userID, enc.Ext)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(f, resp); err != nil {
+		b.metrics.aiError(err)
+		f.Close()
+		b.removeTempFile(f.Name())
+		return nil, err
+	}
+	return f, nil
+}
+
+// joinAudio concatenates parts into a new tracked temp file, with ffmpeg if
+// it is installed and otherwise byte by byte, which only enc.Joinable
+// formats survive.
+func (b *Bot) joinAudio(ctx context.Context, userID int64, parts []*os.File, enc audioEncoding) (*os.File, error) {
+	if b.ffmpeg != "" {
+		var args []string
+		var filter strings.Builder
+		for i, p := range parts {
+			args = append(args, "-i", p.Name())
+			fmt.Fprintf(&filter, "[%d:a]", i)
+		}
+		fmt.Fprintf(&filter, "concat=n=%d:v=0:a=1", len(parts))
+		return b.ffmpegAudio(ctx, userID, enc, append(args, "-filter_complex", filter.String())...)
+	}
+
+	out, err := b.createTempAudio(userID, enc.Ext)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range parts {
+		if _, err = p.Seek(0, io.SeekStart); err == nil {
+			_, err = io.Copy(out, p)
+		}
+		if err != nil {
+			out.Close()
+			b.removeTempFile(out.Name())
+			return nil, err
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,67 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// longScript is numbered sentences well past the TTS input limit.
+func longScript() string {
+	var s strings.Builder
+	for i := 0; s.Len() < 3*maxSpeechInput; i++ {
+		s.WriteString("Sentence number ")
+		s.WriteString(strings.Repeat("x", i%5+1))
+		s.WriteString(" of a long episode. ")
+	}
+	return s.String()
+}
+
+func TestSpeechPartsFitTheLimit(t *testing.T) {
+	script := longScript() + strings.Repeat("y", maxSpeechInput+100)
+	parts := speechParts(script, openai.VoiceNova)
+	if len(parts) < 4 {
+		t.Fatalf("got %d parts, want at least 4", len(parts))
+	}
+	var chunks []string
+	for i, p := range parts {
+		if p.Voice != openai.VoiceNova {
+			t.Errorf("part %d has voice %q", i, p.Voice)
+		}
+		chunks = append(chunks, p.Text)
+	}
+	checkChunks(t, script, maxSpeechInput, chunks)
+	// The oversized word is hard-split into the last two parts.
+	if last := parts[len(parts)-1].Text; strings.Trim(last, "y") != "" {
+		t.Errorf("last part = %q, want the rest of the oversized word", last)
+	}
+}
+
+func TestLongScriptsAreSynthesizedInParts(t *testing.T) {
+	b, tg, ai := newTestBot(t, Config{})
+	var (
+		mu     sync.Mutex
+		inputs []string
+	)
+	ai.speech = func(_ context.Context, req openai.CreateSpeechRequest) (openai.RawResponse, error) {
+		mu.Lock()
+		inputs = append(inputs, req.Input)
+		mu.Unlock()
+		return openai.RawResponse{ReadCloser: io.NopCloser(bytes.NewReader(silentMP3(1)))}, nil
+	}
+	script := longScript()
+	b.recordAudio(testUser, script)
+
+	if len(inputs) < 2 {
+		t.Fatalf("made %d speech calls, want several", len(inputs))
+	}
+	checkChunks(t, script, maxSpeechInput, inputs)
+	if !tg.sentAudio() {
+		t.Error("no audio was sent")
+	}
+}
@@ -0,0 +1,130 @@
+package bot
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// DefaultTelegramWebhookAddr is used when Config.TelegramWebhookAddr is
+// empty.
+const DefaultTelegramWebhookAddr = ":8443"
+
+const (
+	webhookQueueSize       = 100
+	webhookShutdownTimeout = 5 * time.Second
+)
+
+// webhookSecretHeader carries the secret token Telegram was given in
+// setWebhook on every webhook request.
+const webhookSecretHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// webhookSecretRe matches the secret tokens Telegram accepts.
+var webhookSecretRe = regexp.MustCompile(`^[A-Za-z0-9_-]{1,256}$`)
+
+// updates returns the incoming updates until ctx is done: from a Telegram
+// webhook when Config.TelegramWebhookURL is set, otherwise from long
+// polling. Both feed the same dispatch loop in Run.
+func (b *Bot) updates(ctx context.Context) (tgbotapi.UpdatesChannel, error) {
+	if b.cfg.TelegramWebhookURL != "" {
+		return b.listenWebhook(ctx)
+	}
+
+	u := tgbotapi.NewUpdate(0)
+	u.Timeout = 60
+	updates := b.tg.GetUpdatesChan(u)
+	go func() {
+		<-ctx.Done()
+		b.tg.StopReceivingUpdates()
+	}()
+	return updates, nil
+}
+
+// listenWebhook registers the webhook with Telegram and serves it on
+// Config.TelegramWebhookAddr, at the path of the webhook URL. Requests
+// without the registered secret token are rejected.
+func (b *Bot) listenWebhook(ctx context.Context) (tgbotapi.UpdatesChannel, error) {
+	u, err := url.Parse(b.cfg.TelegramWebhookURL)
+	if err != nil {
+		return nil, err
+	}
+	secret := b.cfg.TelegramWebhookSecret
+	if secret == "" {
+		if secret, err = randomWebhookSecret(); err != nil {
+			return nil, err
+		}
+	}
+	// tgbotapi's WebhookConfig predates secret tokens, so set the webhook
+	// by hand.
+	params := tgbotapi.Params{"url": b.cfg.TelegramWebhookURL, "secret_token": secret}
+	if _, err := b.tg.MakeRequest("setWebhook", params); err != nil {
+		return nil, err
+	}
+	ln, err := net.Listen("tcp", b.cfg.TelegramWebhookAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+	ch := make(chan tgbotapi.Update, webhookQueueSize)
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, b.webhookHandler(ctx, secret, ch))
+	srv := &http.Server{Handler: mux}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), webhookShutdownTimeout)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+	go func() {
+		b.log.Info("serving telegram webhook", "addr", ln.Addr().String(), "path", path)
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			b.log.Error("telegram webhook server", "err", err)
+		}
+	}()
+	return ch, nil
+}
+
+// webhookHandler queues the updates Telegram posts to ch, rejecting
+// requests that do not carry secret in their secret token header.
+func (b *Bot) webhookHandler(ctx context.Context, secret string, ch chan<- tgbotapi.Update) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		got := r.Header.Get(webhookSecretHeader)
+		if subtle.ConstantTimeCompare([]byte(got), []byte(secret)) != 1 {
+			http.Error(w, "bad secret token", http.StatusUnauthorized)
+			return
+		}
+		update, err := b.tg.HandleUpdate(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		select {
+		case ch <- *update:
+		case <-ctx.Done():
+			http.Error(w, "shutting down", http.StatusServiceUnavailable)
+		}
+	}
+}
+
+// randomWebhookSecret returns a fresh secret token for setWebhook.
+func randomWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
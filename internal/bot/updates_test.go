@@ -0,0 +1,72 @@
+package bot
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestWebhookChecksSecretToken(t *testing.T) {
+	b, _, _ := newTestBot(t, Config{})
+	ch := make(chan tgbotapi.Update, 1)
+	handler := b.webhookHandler(context.Background(), "s3cret", ch)
+
+	for _, header := range []string{"", "wrong", "s3cre"} {
+		req := httptest.NewRequest(http.MethodPost, "/tg", strings.NewReader(`{"update_id": 1}`))
+		if header != "" {
+			req.Header.Set(webhookSecretHeader, header)
+		}
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("secret %q got status %d, want %d", header, rec.Code, http.StatusUnauthorized)
+		}
+	}
+	if len(ch) != 0 {
+		t.Fatalf("queued %d updates from unauthenticated requests", len(ch))
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/tg", strings.NewReader(`{"update_id": 7}`))
+	req.Header.Set(webhookSecretHeader, "s3cret")
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	if rec.Code != http.StatusOK || len(ch) != 1 || (<-ch).UpdateID != 7 {
+		t.Errorf("the registered secret got status %d", rec.Code)
+	}
+}
+
+func TestWebhookRegistersSecretToken(t *testing.T) {
+	for _, secret := range []string{"", "configured_secret-1"} {
+		b, tg, _ := newTestBot(t, Config{
+			TelegramWebhookURL:    "https://bot.example.com/tg",
+			TelegramWebhookAddr:   "127.0.0.1:0",
+			TelegramWebhookSecret: secret,
+		})
+		ctx, cancel := context.WithCancel(context.Background())
+		if _, err := b.listenWebhook(ctx); err != nil {
+			t.Fatal(err)
+		}
+		cancel()
+
+		tg.mu.Lock()
+		calls := tg.calls
+		tg.mu.Unlock()
+		if len(calls) != 1 || calls[0].endpoint != "setWebhook" || calls[0].params["url"] != "https://bot.example.com/tg" {
+			t.Fatalf("called %+v, want setWebhook with the URL", calls)
+		}
+		got := calls[0].params["secret_token"]
+		if !webhookSecretRe.MatchString(got) || (secret != "" && got != secret) {
+			t.Errorf("registered secret %q, configured %q", got, secret)
+		}
+	}
+}
+
+func TestWebhookSecretIsValidated(t *testing.T) {
+	if _, err := New(&fakeSender{}, &fakeAI{}, Config{TelegramWebhookSecret: "not allowed!", OutputDir: t.TempDir()}); err == nil {
+		t.Error("New accepted a secret Telegram rejects")
+	}
+}
@@ -0,0 +1,33 @@
+package bot
+
+import (
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// BuildInfo identifies the running build, for /version and the health
+// endpoints.
+type BuildInfo struct {
+	Version   string `json:"version,omitempty"`
+	Commit    string `json:"commit,omitempty"`
+	GoVersion string `json:"go_version,omitempty"`
+}
+
+// SetBuildInfo records the running build's version.
+func (b *Bot) SetBuildInfo(info BuildInfo) {
+	b.build = info
+}
+
+func (b *Bot) sendVersion(userID int64) {
+	commit := b.build.Commit
+	if commit == "" {
+		commit = "unknown"
+	}
+	version := b.build.Version
+	if version == "" {
+		version = "unknown"
+	}
+	text := fmt.Sprintf("Podcaster %s\nCommit: %s\nGo: %s", version, commit, b.build.GoVersion)
+	b.tg.Send(tgbotapi.NewMessage(userID, text))
+}
@@ -0,0 +1,39 @@
+package bot
+
+import (
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const voicePrefix = "voice:"
+
+// sendVoices offers the available TTS voices. The choice is stored in the
+// user's show profile, so it survives /new.
+func (b *Bot) sendVoices(userID int64) {
+	current := b.profile(userID).voice()
+
+	var buttons []tgbotapi.InlineKeyboardButton
+	for _, v := range showVoices {
+		label := strings.ToUpper(string(v[:1])) + string(v[1:])
+		if v == current {
+			label = "✅ " + label
+		}
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(label, voicePrefix+string(v)))
+	}
+
+	msg := tgbotapi.NewMessage(userID, b.localized(userID, msgChooseVoice))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(keyboardRows(buttons, 3)...)
+	b.tg.Send(msg)
+}
+
+func (b *Bot) handleVoiceSelection(userID int64, voice string) {
+	st := b.getState(userID)
+	b.mu.Lock()
+	ok := applyShowOption(&st.Prefs.Show, "voice", voice)
+	b.mu.Unlock()
+	if !ok {
+		return
+	}
+	b.tg.Send(tgbotapi.NewMessage(userID, b.localizedf(userID, msgVoiceSet, voice)))
+}
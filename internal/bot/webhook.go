@@ -0,0 +1,79 @@
+package bot
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	webhookAttempts = 3
+	webhookTimeout  = 10 * time.Second
+
+	// SignatureHeader carries the hex HMAC-SHA256 of the webhook body.
+	SignatureHeader = "X-Podcaster-Signature"
+)
+
+var webhookClient = &http.Client{Timeout: webhookTimeout}
+
+// webhookPayload is the JSON body posted for each completed episode.
+type webhookPayload struct {
+	Event   string  `json:"event"`
+	Episode Episode `json:"episode"`
+}
+
+// sendWebhook posts ep to the configured webhook, retrying with
+// exponential backoff on network errors and non-2xx responses.
+func (b *Bot) sendWebhook(ep Episode) {
+	body, err := json.Marshal(webhookPayload{Event: "episode.completed", Episode: ep})
+	if err != nil {
+		b.log.Error("marshal webhook payload", "err", err)
+		return
+	}
+
+	backoff := time.Second
+	for attempt := 1; ; attempt++ {
+		err = b.postWebhook(body)
+		if err == nil {
+			return
+		}
+		if attempt == webhookAttempts {
+			b.log.Error("webhook failed", "user_id", ep.UserID, "attempts", attempt, "err", err)
+			return
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func (b *Bot) postWebhook(body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, b.cfg.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if b.cfg.WebhookSecret != "" {
+		req.Header.Set(SignatureHeader, "sha256="+signPayload(b.cfg.WebhookSecret, body))
+	}
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+func signPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}